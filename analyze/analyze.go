@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/rothskeller/packet/envelope"
 	"github.com/rothskeller/packet/message"
@@ -35,6 +36,13 @@ type Analysis struct {
 	outOf int
 	// analysis is a strings.Builder for building sm.Analysis.
 	analysis *strings.Builder
+	// ackResponse is the local ID of the response acknowledged by the
+	// message, if it is a delivery or read receipt for one of our
+	// responses.  ackRead is true for a read receipt, and ackTime is the
+	// time given in the receipt.
+	ackResponse string
+	ackRead     bool
+	ackTime     time.Time
 }
 
 // astore is the interface that the store passed into analyze package functions
@@ -44,6 +52,9 @@ type astore interface {
 	HasMessageHash(string) string
 	NextMessageID(string) string
 	SaveMessage(*store.Message)
+	FindResponse(subject, mailbox string) string
+	AcknowledgeResponse(id string, read bool, at time.Time)
+	GetExerciseMessage(session int, msgid string) *store.ExerciseMessage
 }
 
 // Analyze analyzes a single received message, and returns its analysis.  The
@@ -83,9 +94,11 @@ func Analyze(st astore, session *store.Session, bbs, raw string) *Analysis {
 		a.msg = message.Decode(a.env, a.body)
 		a.mb = a.msg.Base()
 		a.sm.MessageType = a.mb.Type.Tag
+		a.matchReceipt(st)
 	}
 	// Find the problems with the message.
 	a.analysis = new(strings.Builder)
+	percent := 100
 	if a.messageCounts(err) {
 		a.checkCorrectness()
 		if a.session.ModelMsg == nil {
@@ -93,23 +106,45 @@ func Analyze(st astore, session *store.Session, bbs, raw string) *Analysis {
 		} else {
 			a.compareAgainstModel()
 		}
+		if len(a.session.Route) != 0 {
+			a.checkRoute()
+		}
+		if a.session.ExerciseMsg != nil {
+			a.checkExerciseReply(st)
+		}
+		if !a.session.TriggerAt.IsZero() {
+			percent = a.checkResponseTime()
+		}
 	}
 	if a.sm.Summary == "" && a.score == a.outOf {
 		a.sm.Summary = "OK"
 	}
 	a.sm.Analysis = a.analysis.String()
 	a.sm.Score = a.score * 100 / a.outOf
+	a.sm.Score = scaleScore(a.sm.Score, percent)
 	return &a
 }
 
-// setSummary sets the summary line of the analysis, handling the possibility
-// of multiple issues.
-func (a *Analysis) setSummary(s string) {
+// setSummary records a problem with the message, identified by its problem
+// code (see ProblemLabels), and sets the summary line of the analysis, handling
+// the possibility of multiple issues.
+func (a *Analysis) setSummary(code string) {
+	a.sm.Problems = append(a.sm.Problems, code)
 	if a.sm.Summary != "" {
 		a.sm.Summary = "multiple issues"
 	} else {
-		a.sm.Summary = s
+		a.sm.Summary = ProblemLabel(code)
+	}
+}
+
+// Record returns the message record that Commit would store in the database.
+// Unlike Commit, it does not look up the sender's jurisdiction if the analysis
+// didn't determine it.
+func (a *Analysis) Record() *store.Message {
+	if a == nil {
+		return nil
 	}
+	return &a.sm
 }
 
 // Commit commits the analyzed message to the database.
@@ -121,6 +156,9 @@ func (a *Analysis) Commit(st astore) {
 	}
 	a.fetchJurisdiction()
 	st.SaveMessage(&a.sm)
+	if a.ackResponse != "" {
+		st.AcknowledgeResponse(a.ackResponse, a.ackRead, a.ackTime)
+	}
 	if a.msg != nil {
 		tag = a.msg.Base().Type.Tag
 	} else {
@@ -40,6 +40,14 @@ type testdata struct {
 	Stored      *store.Message   `yaml:"stored"`
 	AnalysisREs []string         `yaml:"analysisREs"`
 	Responses   []*responseCheck `yaml:"responses"`
+	// SentResponse is a response that the store has on file, which the
+	// message might acknowledge.  Acknowledged is the expected kind of
+	// acknowledgment ("delivered" or "read"), if any.
+	SentResponse *store.Response `yaml:"sentResponse"`
+	Acknowledged string          `yaml:"acknowledged"`
+	// Exercise is the exercise message that the store has on file, for a
+	// two-way exercise session.
+	Exercise *store.ExerciseMessage `yaml:"exercise"`
 }
 type responseCheck struct {
 	store.Response `yaml:",inline"`
@@ -49,7 +57,7 @@ type responseCheck struct {
 func TestAnalyze(t *testing.T) {
 	var testfiles []string
 	log.SetOutput(io.Discard)
-	TestForceJurisdiction = "SNY"
+	LookupJurisdiction = func(string) string { return "SNY" }
 	xscmsg.Register()
 	filepath.WalkDir("testdata", func(path string, info fs.DirEntry, err error) error {
 		if strings.HasSuffix(path, ".yaml") && path != "testdata/config.yaml" {
@@ -110,8 +118,12 @@ func testAnalyze(t *testing.T, testfile string) {
 		env, body, _ := envelope.ParseSaved(testdata.Session.ModelMessage)
 		testdata.Session.ModelMsg = message.Decode(env, body)
 	}
+	if testdata.Session.Exercise != "" {
+		env, body, _ := envelope.ParseSaved(testdata.Session.Exercise)
+		testdata.Session.ExerciseMsg = message.Decode(env, body)
+	}
 	// We'll need a fake store for the analyzer to use.
-	store := &fakeStore{seenHash: testdata.SeenHash, nextID: 100}
+	store := &fakeStore{seenHash: testdata.SeenHash, nextID: 100, sent: testdata.SentResponse, exercise: testdata.Exercise}
 	// Run the analysis.
 	a := Analyze(store, testdata.Session, testdata.ToBBS, testdata.Message)
 	responses := a.Responses(store)
@@ -127,6 +139,9 @@ func testAnalyze(t *testing.T, testfile string) {
 	if len(store.saved) > 1 {
 		t.Error("multiple analyses saved to store")
 	}
+	if store.acked != testdata.Acknowledged {
+		t.Errorf("acknowledgment: got %q, expected %q", store.acked, testdata.Acknowledged)
+	}
 	// If we both expected and got an analysis, is it correct?
 	if testdata.Stored != nil && len(store.saved) != 0 {
 		// The raw message in the analysis should be the same as the
@@ -224,6 +239,9 @@ type fakeStore struct {
 	seenHash string
 	nextID   int
 	saved    []*store.Message
+	sent     *store.Response
+	acked    string
+	exercise *store.ExerciseMessage
 }
 
 func (f *fakeStore) HasMessageHash(hash string) string {
@@ -241,3 +259,25 @@ func (f *fakeStore) NextMessageID(prefix string) string {
 func (f *fakeStore) SaveMessage(m *store.Message) {
 	f.saved = append(f.saved, m)
 }
+
+func (f *fakeStore) FindResponse(subject, mailbox string) string {
+	if f.sent != nil && f.sent.Subject == subject && strings.HasPrefix(strings.ToLower(f.sent.To), strings.ToLower(mailbox)+"@") {
+		return f.sent.LocalID
+	}
+	return ""
+}
+
+func (f *fakeStore) AcknowledgeResponse(id string, read bool, at time.Time) {
+	if read {
+		f.acked = "read"
+	} else {
+		f.acked = "delivered"
+	}
+}
+
+func (f *fakeStore) GetExerciseMessage(session int, msgid string) *store.ExerciseMessage {
+	if f.exercise != nil && f.exercise.Session == session && f.exercise.MessageID == msgid {
+		return f.exercise
+	}
+	return nil
+}
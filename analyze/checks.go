@@ -17,6 +17,7 @@ import (
 	"github.com/rothskeller/packet/xscmsg/readrcpt"
 	"github.com/rothskeller/wppsvr/config"
 	"github.com/rothskeller/wppsvr/english"
+	"github.com/rothskeller/wppsvr/store"
 )
 
 var (
@@ -43,14 +44,14 @@ func (a *Analysis) messageCounts(parseErr error) bool {
 	// First, fail immediately if the message is not a human message.
 	if parseErr != nil {
 		a.score, a.outOf = 0, 1
-		a.setSummary("message could not be parsed")
+		a.setSummary("MessageCorrupt")
 		fmt.Fprintf(a.analysis, "<h2>Message Could Not Be Parsed</h2><p>This message could not be parsed as a valid RFC-4155 or RFC-5322 message.  The parse error is “<tt>%s</tt>”.</p>",
 			html.EscapeString(parseErr.Error()))
 		return false
 	}
 	if a.env.Autoresponse {
 		a.score, a.outOf = 0, 1
-		a.setSummary("message has no return address (probably auto-response)")
+		a.setSummary("BounceMessage")
 		a.analysis.WriteString("<h2>Message Has No Return Address</h2><p>This message has no return address, which normally means that it is an auto-response message (e.g., an out-of-office response or a bounce message).  It will not be counted.</p>")
 		return false
 	}
@@ -61,32 +62,46 @@ func (a *Analysis) messageCounts(parseErr error) bool {
 	}
 	if _, ok := a.msg.(*readrcpt.ReadReceipt); ok {
 		a.score, a.outOf = 0, 1
-		a.setSummary("unexpected READ receipt message")
+		if a.ackResponse != "" {
+			// A read receipt for one of our responses is welcome;
+			// it tells us the sender saw our feedback.
+			return false
+		}
+		a.setSummary("ReadReceipt")
 		a.analysis.WriteString(`<h2>Unexpected READ Receipt Message</h2><p>This message is an Outpost “read receipt,” which should not have been sent.  Most likely, your Outpost installation has the “Auto-Read Receipt” setting turned on.  The SCCo “Standard Outpost Configuration Instructions” (available on the <a href="https://www.scc-ares-races.org/services/data/bbs">“Packet BBS Service” page</a> of the county ARES website) specifies that this setting should be turned off.  You can find it on the Receipts tab of the Message Settings dialog in Outpost.</p>`)
 		return false
 	}
 	// Check that it was sent to a correct BBS.
 	if slices.Contains(a.session.DownBBSes, a.sm.ToBBS) {
-		a.setSummary("message to incorrect BBS (simulated outage)")
+		a.setSummary("ToBBSDown")
 		fmt.Fprintf(a.analysis, "<h2>Message to Incorrect BBS</h2><p>This message was sent to %[1]s at %[2]s, but %[2]s has a simulated outage for %[3]s on %[4]s.  This message will not be counted.  Practice messages for this session must be sent to %[1]s at %[5]s.</p>",
 			a.session.CallSign, a.sm.ToBBS, html.EscapeString(a.session.Name), a.session.End.Format("January 2"),
 			english.Conjoin(a.session.ToBBSes, "or"))
 	} else if !slices.Contains(a.session.ToBBSes, a.sm.ToBBS) {
-		a.setSummary("message to incorrect BBS")
+		a.setSummary("ToBBS")
 		fmt.Fprintf(a.analysis, "<h2>Message to Incorrect BBS</h2><p>This message was sent to %[1]s at %[2]s, but practice messages for %[3]s on %[4]s must be sent to %[1]s at %[5]s.  This message will not be counted.</p>",
 			a.session.CallSign, a.sm.ToBBS, html.EscapeString(a.session.Name), a.session.End.Format("January 2"),
 			english.Conjoin(a.session.ToBBSes, "or"))
 	}
 	// Check that it was sent after the start of the session.
-	rcvdate := a.env.BBSReceivedDate
-	if rcvdate.IsZero() {
-		rcvdate = a.env.Date
-	}
+	rcvdate := receivedDate(a.env)
 	if rcvdate.Before(a.session.Start) {
-		a.setSummary("message sent outside of practice session")
+		a.setSummary("MessageTooEarly")
 		fmt.Fprintf(a.analysis, "<h2>Message Sent Outside of Practice Session</h2><p>This message arrived at %s on %s.  However, practice messages for %s aren’t accepted until %s.  This message will not be counted.</p>",
 			a.sm.ToBBS, rcvdate.Format("2006-01-02 at 15:04"), html.EscapeString(a.session.Name),
 			a.session.Start.Format("2006-01-02 at 15:04"))
+	} else if !a.session.TriggerAt.IsZero() && a.session.TriggerSent.IsZero() {
+		// For a timed drill session, check that it was sent after the
+		// trigger message.  Until the trigger message has actually gone
+		// out, nothing can be a response to it.
+		a.setSummary("MessageBeforeTrigger")
+		fmt.Fprintf(a.analysis, "<h2>Message Sent Before Drill Trigger</h2><p>This message arrived at %s on %s.  However, %s is a timed drill, and responses aren’t accepted until the drill trigger message is sent, which hadn’t happened yet.  This message will not be counted.</p>",
+			a.sm.ToBBS, rcvdate.Format("2006-01-02 at 15:04"), html.EscapeString(a.session.Name))
+	} else if !a.session.TriggerAt.IsZero() && rcvdate.Before(a.session.TriggerSent) {
+		a.setSummary("MessageBeforeTrigger")
+		fmt.Fprintf(a.analysis, "<h2>Message Sent Before Drill Trigger</h2><p>This message arrived at %s on %s.  However, %s is a timed drill, and responses aren’t accepted until the drill trigger message is sent at %s.  This message will not be counted.</p>",
+			a.sm.ToBBS, rcvdate.Format("2006-01-02 at 15:04"), html.EscapeString(a.session.Name),
+			a.session.TriggerSent.Format("2006-01-02 at 15:04"))
 	}
 	// Check that we have a call sign so that we know whom to credit.  To do
 	// that, we need to know what BBS the message came from, if any.
@@ -110,7 +125,7 @@ func (a *Analysis) messageCounts(parseErr error) bool {
 		a.sm.Jurisdiction = "XBE"
 	}
 	if a.sm.FromCallSign == "" {
-		a.setSummary("no call sign in message")
+		a.setSummary("NoCallSign")
 		if a.mb.FOpCall != nil {
 			a.analysis.WriteString(`<h2>No Call Sign in Message</h2><p>This message cannot be counted because it’s not clear who sent it.  There is no call sign in the return address or in the Operator Call field of the form.  In order for the message to count, there must be a call sign in at least one of those places.</p>`)
 		} else {
@@ -135,10 +150,10 @@ func (a *Analysis) checkCorrectness() {
 	a.outOf++
 	if a.env.NotPlainText {
 		if strings.Contains(a.env.ReturnAddr, "winlink.org") {
-			a.setSummary("message sent from Winlink")
+			a.setSummary("MessageFromWinlink")
 			a.analysis.WriteString("<h2>Message Sent from Winlink</h2><p>This message was sent from Winlink.  Winlink should not be used for emergency communications in Santa Clara County, unless no alternatives are available, because it uses a message encoding system (“quoted-printable”) that Outpost cannot decode.  As a result, some messages (particularly those with long lines and those containing equals signs) may be garbled in transmission.</p>")
 		} else {
-			a.setSummary("not a plain text message")
+			a.setSummary("MessageNotPlainText")
 			a.analysis.WriteString("<h2>Not a Plain Text Message</h2><p>This message is not a plain text message. All SCCo packet messages should be plain text only.  (“Rich text” or HTML-formatted messages, common in email systems, are far larger than plain text messages and put too much strain on the packet infrastructure.)  Please configure your software to send plain text messages when sending to an SCCo BBS.</p>")
 		}
 	} else {
@@ -147,7 +162,7 @@ func (a *Analysis) checkCorrectness() {
 	// Make sure the message has only ASCII characters.
 	a.outOf++
 	if strings.IndexFunc(a.body, nonASCII) >= 0 {
-		a.setSummary("message has non-ASCII characters")
+		a.setSummary("MessageNotASCII")
 		a.analysis.WriteString("<h2>Message Has Non-ASCII Characters</h2><p>This message contains characters that are not in the standard ASCII character set (i.e., not on a standard keyboard). Non-standard characters should be avoided in packet messages, because the receiving system may not know how to render them.  Note that some software may introduce undesired non-standard characters (e.g., Microsoft Word’s “smart quotes” feature). If you use message text composed in such software, make sure those features are disabled.</p>")
 	} else {
 		a.score++
@@ -155,11 +170,12 @@ func (a *Analysis) checkCorrectness() {
 	// Make sure the message came from a BBS that is up.
 	a.outOf++
 	if slices.Contains(a.session.DownBBSes, a.sm.FromBBS) {
-		a.setSummary("message from incorrect BBS (simulated outage)")
+		a.setSummary("FromBBSDown")
 		fmt.Fprintf(a.analysis, "<h2>Message from Incorrect BBS</h2><p>This message was sent from %s, which has a simulated outage for %s on %s.  Practice messages should not be sent from BBSes that have a simulated outage.</p>",
 			a.sm.FromBBS, html.EscapeString(a.session.Name), a.session.End.Format("January 2"))
 	} else {
 		a.score++
+		a.checkBBSAssignment()
 	}
 	// Some checks only apply to form messages (of known form types).
 	if a.mb.FToICSPosition != nil {
@@ -167,7 +183,7 @@ func (a *Analysis) checkCorrectness() {
 		a.outOf++
 		subject := a.msg.EncodeSubject()
 		if a.subject != subject && a.subject != strings.TrimRight(subject, " ") {
-			a.setSummary("message subject doesn't agree with form contents")
+			a.setSummary("FormSubject")
 			fmt.Fprintf(a.analysis, `<h2>Message Subject Doesn’t Agree with Form Contents</h2><p style="margin-bottom:0">This message has</p><div style="margin-left:2rem"><tt>Subject: %s</tt></div><div>but, based on the contents of the form, it should have</div><div style="margin-left:2rem"><tt>Subject: %s</tt></div><p style="margin-top:0">PackItForms automatically generates the Subject line from the form contents; it should not be overridden manually.</p>`,
 				html.EscapeString(a.subject), html.EscapeString(subject))
 		} else {
@@ -176,7 +192,7 @@ func (a *Analysis) checkCorrectness() {
 		// Make sure the message is valid according to PackItForms' rules.
 		if problems := a.mb.PIFOValid(); len(problems) != 0 {
 			a.outOf += len(problems)
-			a.setSummary("invalid form contents")
+			a.setSummary("FormInvalid")
 			a.analysis.WriteString(`<h2>Invalid Form Contents</h2><p style="margin-bottom:0">This message contains a form with invalid contents:</p><ul style="margin-top:0;margin-bottom:0">`)
 			for _, problem := range problems {
 				fmt.Fprintf(a.analysis, "<li>%s</li>", html.EscapeString(problem))
@@ -188,14 +204,14 @@ func (a *Analysis) checkCorrectness() {
 		minPIFO := config.Get().MinPIFOVersion
 		minForm := config.Get().MessageTypes[a.mb.Type.Tag].MinimumVersion
 		if message.OlderVersion(a.mb.PIFOVersion, minPIFO) {
-			a.setSummary("PackItForms version out of date")
+			a.setSummary("PIFOVersion")
 			fmt.Fprintf(a.analysis, "<h2>PackItForms Version Out of Date</h2><p>This message used version %s of PackItForms to encode the form, but that version is not current.  Please use PackItForms version %s or newer to encode messages containing forms.</p>",
 				a.mb.PIFOVersion, minPIFO)
 		} else {
 			a.score++
 		}
 		if message.OlderVersion(a.mb.Type.Version, minForm) {
-			a.setSummary("form version out of date")
+			a.setSummary("FormVersion")
 			fmt.Fprintf(a.analysis, "<h2>Form Version Out of Date</h2><p>This message contains version %s of the %s, but that version is not current.  Please use version %s or newer of the form.  (You can get the newer form by updating your PackItForms installation.)",
 				a.mb.Type.Version, html.EscapeString(a.mb.Type.Name), minForm)
 		} else {
@@ -204,7 +220,7 @@ func (a *Analysis) checkCorrectness() {
 		// Make sure the form didn't have any spurious fields.
 		a.outOf++
 		if len(a.mb.UnknownFields) != 0 {
-			a.setSummary("form has extra fields")
+			a.setSummary("FormExtraFields")
 			if len(a.mb.UnknownFields) == 1 {
 				fmt.Fprintf(a.analysis, "<h2>Form Has Extra Fields</h2><p>This message contains an extra field (%s) which is not expected in version %s of the %s.",
 					a.mb.UnknownFields[0], a.mb.Type.Version, html.EscapeString(a.mb.Type.Name))
@@ -221,13 +237,13 @@ func (a *Analysis) checkCorrectness() {
 		a.outOf += 3
 		msgid, severity, handling, formtag, _ := message.DecodeSubject(a.subject)
 		if msgid == "" {
-			a.setSummary("incorrect subject line format")
+			a.setSummary("SubjectFormat")
 			a.analysis.WriteString(`<h2>Incorrect Subject Line Format</h2><p>This message has an incorrect subject line format.  According to the SCCo “Standard Packet Message Subject Line” (available on the <a href="https://www.scc-ares-races.org/services/data/bbs">“Packet BBS Service” page</a> of the county ARES website), the subject line should look like <tt>AAA-111P_R_Subject</tt>, where <tt>AAA-111P</tt> is the message number, <tt>R</tt> is the handling order code, and <tt>Subject</tt> is the message subject.</p>`)
 		} else {
 			a.checkMessageNumber()
 			a.score++
 			if severity != "" {
-				a.setSummary("severity on subject line")
+				a.setSummary("SubjectHasSeverity")
 				fmt.Fprintf(a.analysis, `<h2>Severity on Subject Line</h2><p>The subject line of this message contains both a Severity code and a Handling Order code (“_%s/%s_”).  This is an outdated subject line style.  The current SCCo “Standard Packet Message Subject Line” (available on the <a href="https://www.scc-ares-races.org/services/data/bbs">“Packet BBS Service” page</a> of the county ARES website) includes only the Handling Order code on the Subject line (“_%[2]s_”).</p>`,
 					severity, handling)
 			} else {
@@ -237,10 +253,10 @@ func (a *Analysis) checkCorrectness() {
 			case "R", "P", "I":
 				a.score++
 			case "":
-				a.setSummary("missing handling order code")
+				a.setSummary("HandlingOrderMissing")
 				a.analysis.WriteString(`<h2>Missing Handling Order Code on Subject Line</h2><p>The Subject line of this message does not contain a Handling Order code. As documented in the SCCo “Standard Packet Message Subject Line” (available on the <a href="https://www.scc-ares-races.org/services/data/bbs">“Packet BBS Service” page</a> of the county ARES website), it must contain an “I” for Immediate, “P” for Priority, or “R” for Routine.</p>`)
 			default:
-				a.setSummary("unknown handling order code")
+				a.setSummary("HandlingOrderCode")
 				fmt.Fprintf(a.analysis, `<h2>Unknown Handling Order Code on Subject Line</h2><p>The Subject line of this message contains an invalid Handling Order code (“%s”). As documented in the SCCo “Standard Packet Message Subject Line” (available on the <a href="https://www.scc-ares-races.org/services/data/bbs">“Packet BBS Service” page</a> of the county ARES website), the valid codes are “I” for Immediate, “P” for Priority, and “R” for Routine.</p>`,
 					html.EscapeString(handling))
 			}
@@ -250,10 +266,10 @@ func (a *Analysis) checkCorrectness() {
 		if m, ok := a.msg.(*plaintext.PlainText); ok {
 			a.outOf++
 			if strings.Contains(m.Body, "!SCCoPIFO!") || strings.Contains(m.Body, "!PACF!") || strings.Contains(m.Body, "!/ADDON!") {
-				a.setSummary("incorrectly encoded form")
+				a.setSummary("FormCorrupt")
 				a.analysis.WriteString(`<h2>Incorrectly Encoded Form</h2><p>This message appears to contain an encoded form, but the encoding is incorrect.  It appears to have been created or edited by software other than the current PackItForms software.  Please use current PackItForms software to encode messages containing forms.</p>`)
 			} else if formtag != "" {
-				a.setSummary("form name in subject of non-form message")
+				a.setSummary("SubjectPlainForm")
 				fmt.Fprintf(a.analysis, "<h2>Form Name in Subject Line of Non-Form Message</h2><p>This message has a form name (“%s”) on the subject line, but does not contain a recognizable form.  If this is a plain text message, there should be no form name between the handling order code and the subject.  If this is a form message, the form is improperly encoded and could not be recognized.</p>",
 					html.EscapeString(formtag))
 			} else {
@@ -263,6 +279,39 @@ func (a *Analysis) checkCorrectness() {
 	}
 }
 
+// checkBBSAssignment verifies that a message sent from a county BBS was sent
+// from the BBS assigned to the sender's jurisdiction, if the session calls for
+// that check.  The check is skipped if the sender's jurisdiction is unknown.
+func (a *Analysis) checkBBSAssignment() {
+	if a.session.Flags&store.CheckBBSAssignment == 0 || config.Get().BBSes[a.sm.FromBBS] == nil {
+		return
+	}
+	a.fetchJurisdiction()
+	assign := config.Get().BBSAssignments[a.sm.Jurisdiction]
+	if assign == nil {
+		return
+	}
+	expected := assign.Primary
+	if slices.Contains(a.session.DownBBSes, expected) {
+		if expected = assign.Alternate; expected == "" || slices.Contains(a.session.DownBBSes, expected) {
+			return // no assigned BBS is available
+		}
+	}
+	a.outOf++
+	if a.sm.FromBBS == expected {
+		a.score++
+		return
+	}
+	a.setSummary("FromBBSAssignment")
+	if expected == assign.Primary {
+		fmt.Fprintf(a.analysis, `<h2>Message Not from Assigned BBS</h2><p>This message was sent from %s.  According to the “City/Agency BBS Assignments” page (available on the <a href="https://www.scc-ares-races.org/services/data/bbs">“Packet BBS Service” page</a> of the county ARES website), messages from %s should be sent from %s.</p>`,
+			a.sm.FromBBS, a.sm.Jurisdiction, expected)
+	} else {
+		fmt.Fprintf(a.analysis, `<h2>Message Not from Assigned BBS</h2><p>This message was sent from %s.  According to the “City/Agency BBS Assignments” page (available on the <a href="https://www.scc-ares-races.org/services/data/bbs">“Packet BBS Service” page</a> of the county ARES website), messages from %s should be sent from %s, but it has a simulated outage for %s on %s, so they should be sent from the alternate BBS, %s.</p>`,
+			a.sm.FromBBS, a.sm.Jurisdiction, assign.Primary, html.EscapeString(a.session.Name), a.session.End.Format("January 2"), expected)
+	}
+}
+
 func nonASCII(r rune) bool {
 	return r > 126 || (r < 32 && r != '\t' && r != '\n')
 }
@@ -274,13 +323,13 @@ func (a *Analysis) checkMessageNumber() {
 	if msgid != "" {
 		a.outOf++
 		if !msgnumRE.MatchString(msgid) {
-			a.setSummary("incorrect message number format")
+			a.setSummary("MsgNumFormat")
 			a.analysis.WriteString(`<h2>Incorrect Message Number Format</h2><p style="margin-bottom:0">The message number of this message is not formatted correctly.  According to the SCCo “Standard Packet Message Subject Line” document (available on the <a href="https://www.scc-ares-races.org/services/data/bbs">“Packet BBS Service” page</a> of the county ARES website), it should have a format like "XND-042P", containing:</p><ul style="margin-top:0;margin-bottom:0"><li>a three-character prefix (usually the last three characters of the sender's call sign),</li><li>a dash,</li><li>a number with at least three digits, and</li><li>a “P”, “M”, or “R” suffix.</ul><p style="margin-top:0">All letters should be upper case.  In Outpost, the format of the message number is set in the Message Settings dialog, which should be configured according to the SCCo “Standard Outpost Configuration Instructions” (available on the same page).</p>`)
 		} else if fccCallSignRE.MatchString(a.sm.FromCallSign) {
 			act := msgid[:3]
 			exp := a.sm.FromCallSign[len(a.sm.FromCallSign)-3:]
 			if act != exp {
-				a.setSummary("incorrect message number prefix")
+				a.setSummary("MsgNumPrefix")
 				fmt.Fprintf(a.analysis, `<h2>Incorrect Message Number Prefix</h2><p>The message number of this message has the prefix “%s”.  According to the SCCo “Standard Packet Message Subject Line” document (available on the <a href="https://www.scc-ares-races.org/services/data/bbs">“Packet BBS Service” page</a> of the county ARES website), the prefix should be the last three characters of your call sign, “%s”.</p>`,
 					html.EscapeString(act), exp)
 			} else {
@@ -296,9 +345,11 @@ func (a *Analysis) checkMessageNumber() {
 // have a model message to compare against.  Any problems are added to the
 // analysis.
 func (a *Analysis) checkNonModel() {
-	if a.mb.FToICSPosition != nil {
+	if a.mb.FToICSPosition != nil && a.session.ExerciseMsg == nil {
 		// Make sure the message has a destination allowed by the
-		// recommended routing cheat sheet.
+		// recommended routing cheat sheet.  (Replies in two-way
+		// exercises are instead checked against the exercise message
+		// in checkExerciseReply.)
 		var (
 			mtc     *config.MessageTypeConfig
 			badpos  bool
@@ -336,15 +387,15 @@ func (a *Analysis) checkNonModel() {
 			exploc = english.Conjoin(locations, "or")
 		}
 		if badpos && badloc {
-			a.setSummary("incorrect destination for form")
+			a.setSummary("FormDestination")
 			fmt.Fprintf(a.analysis, `<h2>Incorrect Destination for Form</h2><p>This message form is addressed to ICS Position “%s” at Location “%s”.  According to the “SCCo ARES/RACES Recommended Form Routing” document (available on the <a href="https://www.scc-ares-races.org/operations/forms/go-kit">“Go Kit Forms” page</a> of the county ARES website), %ss should be addressed to %s at %s.</p>`,
 				html.EscapeString(*a.mb.FToICSPosition), html.EscapeString(*a.mb.FToLocation), html.EscapeString(a.mb.Type.Name), exppos, exploc)
 		} else if badpos {
-			a.setSummary(`incorrect "To ICS Position" for form`)
+			a.setSummary("FormToICSPosition")
 			fmt.Fprintf(a.analysis, `<h2>Incorrect “To ICS Position” for Form</h2><p>This message form is addressed to ICS Position “%s”.  According to the “SCCo ARES/RACES Recommended Form Routing” document (available on the <a href="https://www.scc-ares-races.org/operations/forms/go-kit">“Go Kit Forms” page</a> of the county ARES website), %ss should be addressed to ICS Position %s.</p>`,
 				html.EscapeString(*a.mb.FToICSPosition), html.EscapeString(a.mb.Type.Name), exppos)
 		} else if badloc {
-			a.setSummary(`incorrect "To Location" for form`)
+			a.setSummary("FormToLocation")
 			fmt.Fprintf(a.analysis, `<h2>Incorrect “To Location” for Form</h2><p>This message form is addressed to Location “%s”.  According to the “SCCo ARES/RACES Recommended Form Routing” document (available on the <a href="https://www.scc-ares-races.org/operations/forms/go-kit">“Go Kit Forms” page</a> of the county ARES website), %ss should be addressed to Location %s.</p>`,
 				html.EscapeString(*a.mb.FToLocation), html.EscapeString(a.mb.Type.Name), exploc)
 		}
@@ -360,7 +411,7 @@ func (a *Analysis) checkNonModel() {
 				acthand = *b.FHandling
 			}
 			if exphand != "" && exphand != acthand {
-				a.setSummary("incorrect handling order for form")
+				a.setSummary("FormHandlingOrder")
 				fmt.Fprintf(a.analysis, `<h2>Incorrect Handling Order for Form</h2><p>This message has handling order “%s”.  According to the “SCCo ARES/RACES Recommended Form Routing” document (available on the <a href="https://www.scc-ares-races.org/operations/forms/go-kit">“Go Kit Forms” page</a> of the county ARES website), it should have handling order “%s”.</p>`,
 					html.EscapeString(acthand), exphand)
 			} else {
@@ -394,7 +445,7 @@ func (a *Analysis) checkNonModel() {
 				article = mtype.Article
 			}
 		}
-		a.setSummary("incorrect message type")
+		a.setSummary("MessageTypeWrong")
 		fmt.Fprintf(a.analysis, "<h2>Incorrect Message Type</h2><p>This message is %s %s.  For the %s on %s, %s %s is expected.</p>",
 			a.mb.Type.Article, html.EscapeString(a.mb.Type.Name), html.EscapeString(a.session.Name),
 			a.session.End.Format("January 2"), article, english.Conjoin(allowed, "or"))
@@ -409,28 +460,31 @@ func (a *Analysis) compareAgainstModel() {
 	// Make sure the received message is the same type as the model.
 	if a.mb.Type.Tag != a.session.ModelMsg.Base().Type.Tag {
 		a.outOf *= 2 // Give a 50% score.
-		a.setSummary("incorrect message type")
+		a.setSummary("MessageTypeWrong")
 		fmt.Fprintf(a.analysis, "<h2>Incorrect Message Type</h2><p>This message is %s %s.  For the %s on %s, operators are expected to send a copy of the provided %s.</p>",
 			a.mb.Type.Article, html.EscapeString(a.mb.Type.Name), html.EscapeString(a.session.Name),
 			a.session.End.Format("January 2"), html.EscapeString(a.session.ModelMsg.Base().Type.Name))
 		return
 	}
 	// Compare the message against the model.
-	score, outOf, fields := a.session.ModelMsg.Compare(a.msg)
+	_, _, fields := a.session.ModelMsg.Compare(a.msg)
 	// The model may have left destination or handling blank, as an exercise
 	// for the operator to look them up in the recommended routing cheat
 	// sheet.  If so, we need to fix up the results of the comparison for
 	// that.
 	var recRouteMismatch []string
 	if mtc := config.Get().MessageTypes[a.session.ModelMsg.Base().Type.Tag]; mtc != nil {
-		score, recRouteMismatch = a.fixupRecRouteFields(score, fields, mtc)
+		recRouteMismatch = a.fixupRecRouteFields(fields, mtc)
 	}
+	// Apply the configured comparison rules and the session's field
+	// weights to get the final score.
+	score, outOf := a.applyComparisonRules(fields)
 	a.score += score
 	a.outOf += outOf
 	if score == outOf {
 		return // No need to emit the comparison.
 	}
-	a.setSummary("message not transcribed correctly")
+	a.setSummary("ModelMismatch")
 	a.analysis.WriteString(`<h2>Message Not Transcribed Correctly</h2><p>There are differences between this message and the model message provided for this practice session:</p><div class="comparison"><div class="head"><div class="label">Field Name</div><div class="vmodel">Model Message</div><div class="vrecv">Received Message</div></div>`)
 	for _, f := range fields {
 		fmt.Fprintf(a.analysis, `<div class="field"><div class="label">%s</div><div class="vmodel">%s</div><div class="vrecv">%s</div></div>`,
@@ -452,7 +506,7 @@ func (a *Analysis) compareAgainstModel() {
 // fixupRecRouteFields modifies the comparison of the fields covered by the
 // recommended routing cheat sheet, to address the possibility that they weren't
 // supplied in the model message.
-func (a *Analysis) fixupRecRouteFields(score int, fields []*message.CompareField, mtc *config.MessageTypeConfig) (_ int, mismatches []string) {
+func (a *Analysis) fixupRecRouteFields(fields []*message.CompareField, mtc *config.MessageTypeConfig) (mismatches []string) {
 	for _, f := range fields {
 		switch f.Label {
 		case "To ICS Position":
@@ -460,14 +514,12 @@ func (a *Analysis) fixupRecRouteFields(score int, fields []*message.CompareField
 				f.ExpectedMask = "_"
 				if slices.Contains(mtc.ToICSPosition, f.Actual) {
 					f.Expected = f.Actual
-					score += f.OutOf - f.Score
 					f.Score = f.OutOf
 					f.ActualMask = " "
 				} else {
 					mismatches = append(mismatches, "“To ICS Position”")
 					f.Expected = english.Conjoin(mtc.ToICSPosition, "or")
 					f.Label += " [See NOTE]"
-					score -= f.Score
 					f.Score = 0
 					f.ActualMask = "*"
 				}
@@ -477,14 +529,12 @@ func (a *Analysis) fixupRecRouteFields(score int, fields []*message.CompareField
 				f.ExpectedMask = "_"
 				if slices.Contains(mtc.ToLocation, f.Actual) {
 					f.Expected = f.Actual
-					score += f.OutOf - f.Score
 					f.Score = f.OutOf
 					f.ActualMask = " "
 				} else {
 					mismatches = append(mismatches, "“To Location”")
 					f.Expected = english.Conjoin(mtc.ToLocation, "or")
 					f.Label += " [See NOTE]"
-					score -= f.Score
 					f.Score = 0
 					f.ActualMask = "*"
 				}
@@ -499,13 +549,11 @@ func (a *Analysis) fixupRecRouteFields(score int, fields []*message.CompareField
 					f.Expected = handling
 					f.ExpectedMask = "_"
 					if handling == f.Actual {
-						score += f.OutOf - f.Score
 						f.Score = f.OutOf
 						f.ActualMask = " "
 					} else {
 						mismatches = append(mismatches, "“Handling”")
 						f.Label += " [See NOTE]"
-						score -= f.Score
 						f.Score = 0
 						f.ActualMask = "*"
 					}
@@ -513,7 +561,7 @@ func (a *Analysis) fixupRecRouteFields(score int, fields []*message.CompareField
 			}
 		}
 	}
-	return score, mismatches
+	return mismatches
 }
 
 func formatFieldValue(value, mask string) string {
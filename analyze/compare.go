@@ -0,0 +1,194 @@
+package analyze
+
+// This file contains the comparison rules layered on top of the packet
+// library's comparison of a received message against the model message.  The
+// packet library decides which fields match; the rules here can forgive
+// trivial differences, give partial credit for near misses, and weight fields
+// differently, as specified by the "comparison" section of config.yaml and the
+// session's field weights.
+
+import (
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/rothskeller/packet/message"
+	"github.com/rothskeller/wppsvr/config"
+)
+
+// applyComparisonRules adjusts the field scores from the packet library's
+// comparison according to the configured comparison rules, replaces the
+// difference masks of mismatched fields with character-level differences, and
+// returns the weighted score and maximum score for the comparison.
+func (a *Analysis) applyComparisonRules(fields []*message.CompareField) (score, outOf int) {
+	for _, f := range fields {
+		weight := 1
+		if w, ok := a.session.FieldWeights[strings.TrimSuffix(f.Label, " [See NOTE]")]; ok {
+			weight = w
+		}
+		// Fields whose expected values came from the recommended
+		// routing cheat sheet have already been handled.
+		if f.Score < f.OutOf && f.ExpectedMask != "_" && f.Expected != f.Actual {
+			rules := comparisonRules(f.Label)
+			minor := !strings.Contains(f.ExpectedMask+f.ActualMask, "*")
+			f.ExpectedMask, f.ActualMask = diffMasks(f.Expected, f.Actual)
+			if minor {
+				// The packet library considered the difference
+				// minor; keep it that way.
+				f.ExpectedMask = strings.ReplaceAll(f.ExpectedMask, "*", "~")
+				f.ActualMask = strings.ReplaceAll(f.ActualMask, "*", "~")
+			}
+			if rules != nil {
+				exp := normalizeValue(f.Expected, rules.Normalize)
+				act := normalizeValue(f.Actual, rules.Normalize)
+				if exp == act {
+					// The difference is trivial; forgive it,
+					// but show it as a minor difference.
+					f.Score = f.OutOf
+					f.ExpectedMask = strings.ReplaceAll(f.ExpectedMask, "*", "~")
+					f.ActualMask = strings.ReplaceAll(f.ActualMask, "*", "~")
+				} else if rules.PartialCredit != 0 {
+					if sim := similarity(exp, act); sim >= rules.PartialCredit {
+						if credit := f.OutOf * sim / 100; credit > f.Score {
+							f.Score = credit
+						}
+					}
+				}
+			}
+		}
+		score += f.Score * weight
+		outOf += f.OutOf * weight
+	}
+	return score, outOf
+}
+
+// comparisonRules returns the comparison rules for the field with the
+// specified label, or nil if there are none.
+func comparisonRules(label string) *config.ComparisonConfig {
+	rules := config.Get().Comparison
+	if cc := rules[strings.TrimSuffix(label, " [See NOTE]")]; cc != nil {
+		return cc
+	}
+	return rules["*"]
+}
+
+// dateFormats are the date formats recognized by the "date" normalization.
+var dateFormats = []string{
+	"01/02/2006", "1/2/2006", "01/02/06", "1/2/06", "2006-01-02", "2006/01/02",
+	"01-02-2006", "1-2-2006", "Jan 2 2006", "Jan 2, 2006", "January 2 2006",
+	"January 2, 2006", "2 Jan 2006", "2 January 2006", "02Jan2006", "02JAN2006",
+}
+
+// normalizeValue applies the specified normalizations to a field value.
+func normalizeValue(value string, normalize []string) string {
+	for _, norm := range []string{"date", "whitespace", "case", "punctuation"} {
+		if !slices.Contains(normalize, norm) {
+			continue
+		}
+		switch norm {
+		case "date":
+			trimmed := strings.TrimSpace(value)
+			for _, format := range dateFormats {
+				if d, err := time.Parse(format, trimmed); err == nil {
+					value = d.Format("01/02/2006")
+					break
+				}
+			}
+		case "whitespace":
+			value = strings.Join(strings.Fields(value), " ")
+		case "case":
+			value = strings.ToUpper(value)
+		case "punctuation":
+			value = strings.Join(strings.Fields(strings.Map(func(r rune) rune {
+				if unicode.IsPunct(r) {
+					return ' '
+				}
+				return r
+			}, value)), " ")
+		}
+	}
+	return value
+}
+
+// similarity returns the similarity of two strings, as a percentage, based on
+// the edit distance between them.
+func similarity(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	longer := len(ar)
+	if len(br) > longer {
+		longer = len(br)
+	}
+	if longer == 0 {
+		return 100
+	}
+	return 100 - editDistance(ar, br)*100/longer
+}
+
+// editDistance returns the Levenshtein distance between two strings.
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// diffMasks returns difference masks for two strings, suitable for
+// formatFieldValue.  Characters that are part of the longest common
+// subsequence of the two strings are marked with spaces; all others are marked
+// with asterisks.  The masks have one byte per byte of the corresponding
+// string.
+func diffMasks(a, b string) (amask, bmask string) {
+	ar, br := []rune(a), []rune(b)
+	// lcs[i][j] is the length of the longest common subsequence of ar[i:]
+	// and br[j:].
+	lcs := make([][]int, len(ar)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(br)+1)
+	}
+	for i := len(ar) - 1; i >= 0; i-- {
+		for j := len(br) - 1; j >= 0; j-- {
+			if ar[i] == br[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var am, bm strings.Builder
+	var i, j int
+	for i < len(ar) && j < len(br) {
+		switch {
+		case ar[i] == br[j]:
+			am.WriteString(strings.Repeat(" ", len(string(ar[i]))))
+			bm.WriteString(strings.Repeat(" ", len(string(br[j]))))
+			i, j = i+1, j+1
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			am.WriteString(strings.Repeat("*", len(string(ar[i]))))
+			i++
+		default:
+			bm.WriteString(strings.Repeat("*", len(string(br[j]))))
+			j++
+		}
+	}
+	for ; i < len(ar); i++ {
+		am.WriteString(strings.Repeat("*", len(string(ar[i]))))
+	}
+	for ; j < len(br); j++ {
+		bm.WriteString(strings.Repeat("*", len(string(br[j]))))
+	}
+	return am.String(), bm.String()
+}
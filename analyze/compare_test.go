@@ -0,0 +1,76 @@
+package analyze
+
+import (
+	"testing"
+
+	"github.com/rothskeller/packet/message"
+	"github.com/rothskeller/wppsvr/config"
+	"github.com/rothskeller/wppsvr/store"
+)
+
+func TestNormalizeValue(t *testing.T) {
+	tests := []struct {
+		in, out   string
+		normalize []string
+	}{
+		{"  Test   Message ", "Test Message", []string{"whitespace"}},
+		{"Test Message", "TEST MESSAGE", []string{"case"}},
+		{"Test, Message.", "Test Message", []string{"punctuation"}},
+		{"1/9/22", "01/09/2022", []string{"date"}},
+		{"2022-01-09", "01/09/2022", []string{"date"}},
+		{"not a date", "not a date", []string{"date"}},
+	}
+	for _, tt := range tests {
+		if out := normalizeValue(tt.in, tt.normalize); out != tt.out {
+			t.Errorf("normalizeValue(%q, %v) = %q, want %q", tt.in, tt.normalize, out, tt.out)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	if sim := similarity("Hello", "Helo"); sim != 80 {
+		t.Errorf("similarity(Hello, Helo) = %d, want 80", sim)
+	}
+	if sim := similarity("", ""); sim != 100 {
+		t.Errorf("similarity of empty strings = %d, want 100", sim)
+	}
+}
+
+func TestDiffMasks(t *testing.T) {
+	amask, bmask := diffMasks("Test Message", "Text Mesage")
+	if amask != "  *     *   " || bmask != "  *        " {
+		t.Errorf("diffMasks = %q, %q", amask, bmask)
+	}
+}
+
+// TestUnweightedComparison verifies that, with no comparison rules and no
+// field weights, the score of a comparison is exactly what it was before
+// those were introduced:  the packet library's score, adjusted for the fields
+// covered by the recommended routing cheat sheet.
+func TestUnweightedComparison(t *testing.T) {
+	config.SetConfig(&config.Config{})
+	a := &Analysis{session: &store.Session{}}
+	fields := []*message.CompareField{
+		{Label: "Subject", Score: 10, OutOf: 10, Expected: "Test", Actual: "Test"},
+		{Label: "Message", Score: 2, OutOf: 10, Expected: "Hello", Actual: "Help", ExpectedMask: "   *", ActualMask: "   *"},
+		{Label: "To ICS Position", Score: 0, OutOf: 5, Actual: "Planning"},
+		{Label: "To Location", Score: 0, OutOf: 5, Actual: "Nowhere"},
+		{Label: "Handling", Score: 0, OutOf: 5, Actual: "ROUTINE"},
+	}
+	// The library's score is 12 out of 35.  The To ICS Position field
+	// matches the cheat sheet, adding 5; the To Location field doesn't,
+	// and Handling isn't on the cheat sheet for this type.
+	mismatches := a.fixupRecRouteFields(fields, &config.MessageTypeConfig{
+		ToICSPosition: []string{"Planning", "Operations"},
+		ToLocation:    []string{"County EOC"},
+	})
+	if len(mismatches) != 1 || mismatches[0] != "“To Location”" {
+		t.Errorf("mismatches = %v", mismatches)
+	}
+	if score, outOf := a.applyComparisonRules(fields); score != 17 || outOf != 35 {
+		t.Errorf("applyComparisonRules = %d/%d, want 17/35", score, outOf)
+	}
+	if fields[1].Score != 2 {
+		t.Errorf("Message field score = %d, want 2", fields[1].Score)
+	}
+}
@@ -0,0 +1,83 @@
+package analyze
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rothskeller/packet/envelope"
+	"github.com/rothskeller/wppsvr/config"
+	"github.com/rothskeller/wppsvr/store"
+)
+
+// checkResponseTime checks how long after the trigger message of a timed drill
+// session the message arrived at the BBS, and returns the percentage of credit
+// it gets for that response time.
+func (a *Analysis) checkResponseTime() (percent int) {
+	var (
+		latency = receivedDate(a.env).Sub(a.session.TriggerSent)
+		target  = config.Get().Drill.Brackets[0].Minutes
+		minutes int
+	)
+	if minutes, percent = DrillBracket(latency); percent == 100 {
+		return percent
+	}
+	a.setSummary("ResponseSlow")
+	if minutes != 0 {
+		fmt.Fprintf(a.analysis, "<h2>Slow Response</h2><p>This message arrived at %s %s after the drill trigger message was sent.  Responses arriving within %d minutes get full credit.  Those arriving within %d minutes, like this one, get %d%% credit.</p>",
+			a.sm.ToBBS, FormatLatency(latency), target, minutes, percent)
+	} else {
+		fmt.Fprintf(a.analysis, "<h2>Slow Response</h2><p>This message arrived at %s %s after the drill trigger message was sent.  Responses arriving within %d minutes get full credit.  Those arriving as late as this one get %d%% credit.</p>",
+			a.sm.ToBBS, FormatLatency(latency), target, percent)
+	}
+	return percent
+}
+
+// DrillBracket returns the configured response time bracket for a check-in to
+// a timed drill session that arrived the specified time after the trigger
+// message:  its upper limit in minutes (or zero if the check-in was later than
+// all of the brackets), and the percentage of credit given to check-ins in it.
+func DrillBracket(latency time.Duration) (minutes, percent int) {
+	var drill = config.Get().Drill
+
+	for _, b := range drill.Brackets {
+		if latency <= time.Duration(b.Minutes)*time.Minute {
+			return b.Minutes, b.Score
+		}
+	}
+	return 0, drill.LateScore
+}
+
+// scaleScore scales a message score by the percentage of credit given for its
+// response time, but not so far that a counted message stops counting.
+func scaleScore(score, percent int) int {
+	if percent == 100 || score == 0 {
+		return score
+	}
+	return max(score*percent/100, 1)
+}
+
+// ReceivedDate returns the time at which a stored message arrived at the BBS,
+// which is the time its response time is measured to in a timed drill session.
+func ReceivedDate(m *store.Message) time.Time {
+	if env, _, err := envelope.ParseRetrieved(m.Message, m.ToBBS, ""); err == nil {
+		if rcvdate := receivedDate(env); !rcvdate.IsZero() {
+			return rcvdate
+		}
+	}
+	return m.DeliveryTime
+}
+
+// receivedDate returns the time at which the message with the specified
+// envelope arrived at the BBS, if known, or else the time it was sent.
+func receivedDate(env *envelope.Envelope) time.Time {
+	if !env.BBSReceivedDate.IsZero() {
+		return env.BBSReceivedDate
+	}
+	return env.Date
+}
+
+// FormatLatency formats a response time for display, rounded to the second
+// (e.g., "1h4m12s").
+func FormatLatency(latency time.Duration) string {
+	return latency.Round(time.Second).String()
+}
@@ -0,0 +1,47 @@
+package analyze
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rothskeller/wppsvr/config"
+)
+
+func TestDrillBracket(t *testing.T) {
+	config.SetConfig(&config.Config{Drill: config.DrillConfig{
+		Brackets:  config.DefaultDrillBrackets,
+		LateScore: config.DefaultDrillLateScore,
+	}})
+	tests := []struct {
+		latency          time.Duration
+		minutes, percent int
+	}{
+		{10 * time.Minute, 15, 100},
+		{15 * time.Minute, 15, 100},
+		{15*time.Minute + time.Second, 30, 90},
+		{45 * time.Minute, 60, 75},
+		{2 * time.Hour, 120, 50},
+		{3 * time.Hour, 0, 25},
+	}
+	for _, tt := range tests {
+		if minutes, percent := DrillBracket(tt.latency); minutes != tt.minutes || percent != tt.percent {
+			t.Errorf("DrillBracket(%s) = %d, %d, want %d, %d", tt.latency, minutes, percent, tt.minutes, tt.percent)
+		}
+	}
+}
+
+func TestScaleScore(t *testing.T) {
+	tests := []struct{ score, percent, out int }{
+		{90, 100, 90},
+		{80, 50, 40},
+		{100, 25, 25},
+		{1, 25, 1},
+		{3, 25, 1},
+		{0, 50, 0},
+	}
+	for _, tt := range tests {
+		if out := scaleScore(tt.score, tt.percent); out != tt.out {
+			t.Errorf("scaleScore(%d, %d) = %d, want %d", tt.score, tt.percent, out, tt.out)
+		}
+	}
+}
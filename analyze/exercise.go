@@ -0,0 +1,124 @@
+package analyze
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/rothskeller/packet/message"
+	"github.com/rothskeller/wppsvr/config"
+	"github.com/rothskeller/wppsvr/english"
+	"github.com/rothskeller/wppsvr/store"
+)
+
+// checkExerciseReply checks a message received in a two-way exercise session,
+// which should be a reply to the exercise message sent to its sender.  The
+// reply must reference the message number of that exercise message, be
+// addressed back to the position and location that sent it, have content, and
+// arrive promptly.  Any problems are added to the analysis.
+func (a *Analysis) checkExerciseReply(st astore) {
+	var (
+		em  *store.ExerciseMessage
+		xmb = a.session.ExerciseMsg.Base()
+		ref string
+	)
+	// Make sure the reply references the exercise message sent to its
+	// sender.
+	a.outOf++
+	if a.mb.FReference != nil {
+		ref = strings.ToUpper(strings.TrimSpace(*a.mb.FReference))
+	}
+	if ref != "" {
+		em = st.GetExerciseMessage(a.session.ID, ref)
+	}
+	if em != nil && em.Error != "" {
+		em = nil // that copy was never sent, so it can't be replied to
+	}
+	switch {
+	case em != nil && em.CallSign == a.sm.FromCallSign:
+		a.score++
+	case em != nil:
+		a.setSummary("ReplyReference")
+		fmt.Fprintf(a.analysis, "<h2>Reply to Wrong Message</h2><p>This message references message number %s.  However, that exercise message was sent to %s, not to %s.  Your reply should reference the message number of the exercise message you received.</p>",
+			html.EscapeString(ref), em.CallSign, a.sm.FromCallSign)
+		em = nil
+	case a.mb.FReference == nil:
+		a.setSummary("ReplyReference")
+		fmt.Fprintf(a.analysis, "<h2>Reply Has No Reference</h2><p>This message has no Reference field, so it can’t reference the exercise message it is replying to.  For %s, the reply should be %s with the message number of the exercise message in its Reference field.</p>",
+			html.EscapeString(a.session.Name), replyTypes(a.session))
+	default:
+		a.setSummary("ReplyReference")
+		fmt.Fprintf(a.analysis, "<h2>Reply Doesn’t Reference Exercise Message</h2><p>This message has “%s” in its Reference field, which is not the message number of an exercise message sent for %s.  Your reply should reference the message number of the exercise message you received.</p>",
+			html.EscapeString(ref), html.EscapeString(a.session.Name))
+	}
+	// Make sure the reply is addressed back to the sender of the exercise
+	// message.
+	if pos, loc := fieldValue(xmb.FFromICSPosition), fieldValue(xmb.FFromLocation); a.mb.FToICSPosition != nil && pos != "" {
+		var (
+			topos = strings.TrimSpace(fieldValue(a.mb.FToICSPosition))
+			toloc = strings.TrimSpace(fieldValue(a.mb.FToLocation))
+		)
+		a.outOf++
+		if !strings.EqualFold(topos, pos) || (loc != "" && !strings.EqualFold(toloc, loc)) {
+			a.setSummary("ReplyDestination")
+			fmt.Fprintf(a.analysis, "<h2>Reply Not Addressed to Sender</h2><p>This message is addressed to ICS Position “%s” at Location “%s”.  Replies should be addressed to the sender of the exercise message:  ICS Position “%s” at Location “%s”.</p>",
+				html.EscapeString(topos), html.EscapeString(toloc), html.EscapeString(pos), html.EscapeString(loc))
+		} else {
+			a.score++
+		}
+	}
+	// Make sure the reply says something.
+	if a.mb.FBody != nil {
+		a.outOf++
+		if strings.TrimSpace(*a.mb.FBody) == "" {
+			a.setSummary("ReplyEmpty")
+			a.analysis.WriteString("<h2>Reply Has No Content</h2><p>This message has an empty message body.  Your reply should answer the exercise message.</p>")
+		} else {
+			a.score++
+		}
+	}
+	// Make sure the reply was sent promptly.  (We can only tell if we know
+	// which exercise message it replies to.)
+	if em != nil {
+		var (
+			hours      = config.Get().ReplyHours
+			turnaround = receivedDate(a.env).Sub(em.Sent)
+		)
+		a.outOf++
+		if turnaround > time.Duration(hours)*time.Hour {
+			a.setSummary("ReplySlow")
+			fmt.Fprintf(a.analysis, "<h2>Slow Reply</h2><p>This message arrived at %s %s after the exercise message it replies to was sent.  Replies should arrive within %d hours.</p>",
+				a.sm.ToBBS, FormatLatency(turnaround), hours)
+		} else {
+			a.score++
+		}
+	}
+}
+
+// fieldValue returns the value of an optional message field, or an empty string
+// if the message doesn't have the field.
+func fieldValue(f *string) string {
+	if f == nil {
+		return ""
+	}
+	return *f
+}
+
+// replyTypes returns a description of the message types accepted as replies in
+// a two-way exercise session, for use in analysis text.
+func replyTypes(session *store.Session) string {
+	var (
+		names   []string
+		article = "a"
+	)
+	for i, code := range session.MessageTypes {
+		if mt := message.RegisteredTypes[code]; mt != nil {
+			names = append(names, html.EscapeString(mt[0].Name))
+			if i == 0 {
+				article = mt[0].Article
+			}
+		}
+	}
+	return article + " " + english.Conjoin(names, "or")
+}
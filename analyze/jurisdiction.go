@@ -8,10 +8,13 @@ import (
 	"time"
 )
 
-// TestForceJurisdiction is normally an empty string.  When set to a nonempty
-// value by tests, it disables the web requests to fetch ham information and
-// instead sets all jurisdictions to the value of TestForceJurisdiction.
-var TestForceJurisdiction string
+// LookupJurisdiction looks up the jurisdiction of a sender with an FCC call
+// sign, when the message itself doesn't identify it.  It is called during
+// analysis (when the session checks BBS assignments) and when committing the
+// analysis.  By default, it queries the county ARES database over the network.
+// Tools that must work offline set it to nil, or to a stub; when it is nil, the
+// jurisdiction of such senders remains unknown.
+var LookupJurisdiction = fetchSCCoJurisdiction
 
 var jurisdictionMap = map[string]string{
 	"Alameda County":                    "XAL",
@@ -94,7 +97,25 @@ type getHamInfoResponse struct {
 	OtherAgencies []string
 }
 
+// fetchJurisdiction sets the jurisdiction of the sender, if it isn't already
+// known.
 func (a *Analysis) fetchJurisdiction() {
+	if a.sm.FromCallSign == "" || a.sm.Jurisdiction != "" {
+		return
+	}
+	if !fccCallSignRE.MatchString(a.sm.FromCallSign) {
+		a.sm.Jurisdiction = a.sm.FromCallSign[:3]
+		return
+	}
+	if LookupJurisdiction != nil {
+		a.sm.Jurisdiction = LookupJurisdiction(a.sm.FromCallSign)
+	}
+}
+
+// fetchSCCoJurisdiction fetches the jurisdiction of the specified call sign
+// from the county ARES database.  It returns an empty string if the
+// jurisdiction can't be determined.
+func fetchSCCoJurisdiction(callsign string) (jurisdiction string) {
 	var (
 		timeout context.Context
 		cancel  context.CancelFunc
@@ -103,49 +124,39 @@ func (a *Analysis) fetchJurisdiction() {
 		ghis    []*getHamInfoResponse
 		err     error
 	)
-	if a.sm.FromCallSign == "" {
-		return
-	}
-	if !fccCallSignRE.MatchString(a.sm.FromCallSign) {
-		a.sm.Jurisdiction = a.sm.FromCallSign[:3]
-		return
-	}
-	if TestForceJurisdiction != "" {
-		a.sm.Jurisdiction = TestForceJurisdiction
-		return
-	}
 	timeout, cancel = context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	req, err = http.NewRequestWithContext(timeout, http.MethodGet,
-		"https://www.scc-ares-races.org/activities/getHamInfo.php?id="+a.sm.FromCallSign, nil)
+		"https://www.scc-ares-races.org/activities/getHamInfo.php?id="+callsign, nil)
 	if err != nil {
 		panic(err)
 	}
 	resp, err = http.DefaultClient.Do(req)
 	if err != nil {
-		log.Printf("ERROR: unable to fetch SCCo database info for %s: %s", a.sm.FromCallSign, err)
-		return
+		log.Printf("ERROR: unable to fetch SCCo database info for %s: %s", callsign, err)
+		return ""
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
-		log.Printf("ERROR: unable to fetch SCCo database info for %s: status code %d", a.sm.FromCallSign, resp.StatusCode)
-		return
+		log.Printf("ERROR: unable to fetch SCCo database info for %s: status code %d", callsign, resp.StatusCode)
+		return ""
 	}
 	if err = json.NewDecoder(resp.Body).Decode(&ghis); err != nil {
-		log.Printf("ERROR: unable to fetch SCCo database info for %s: json.Decode: %s", a.sm.FromCallSign, err)
-		return
+		log.Printf("ERROR: unable to fetch SCCo database info for %s: json.Decode: %s", callsign, err)
+		return ""
 	}
 	if len(ghis) != 1 {
-		log.Printf("ERROR: unable to fetch SCCo database info for %s: %d responses", a.sm.FromCallSign, len(ghis))
-		return
+		log.Printf("ERROR: unable to fetch SCCo database info for %s: %d responses", callsign, len(ghis))
+		return ""
 	}
-	a.sm.Jurisdiction = jurisdictionMap[ghis[0].HomeAgency]
-	if a.sm.Jurisdiction == "" || a.sm.Jurisdiction == "XSC" || a.sm.Jurisdiction == "HOS" {
+	jurisdiction = jurisdictionMap[ghis[0].HomeAgency]
+	if jurisdiction == "" || jurisdiction == "XSC" || jurisdiction == "HOS" {
 		for _, other := range ghis[0].OtherAgencies {
 			if juris := jurisdictionMap[other]; juris != "" && juris != "XSC" && juris != "HOS" {
-				a.sm.Jurisdiction = juris
+				jurisdiction = juris
 				break
 			}
 		}
 	}
+	return jurisdiction
 }
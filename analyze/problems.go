@@ -0,0 +1,53 @@
+package analyze
+
+// ProblemLabels maps each problem code to the human-readable summary used for
+// it in message analyses and reports.
+var ProblemLabels = map[string]string{
+	"BounceMessage":        "message has no return address (probably auto-response)",
+	"FormCorrupt":          "incorrectly encoded form",
+	"FormDestination":      "incorrect destination for form",
+	"FormExtraFields":      "form has extra fields",
+	"FormHandlingOrder":    "incorrect handling order for form",
+	"FormInvalid":          "invalid form contents",
+	"FormSubject":          "message subject doesn't agree with form contents",
+	"FormToICSPosition":    `incorrect "To ICS Position" for form`,
+	"FormToLocation":       `incorrect "To Location" for form`,
+	"FormVersion":          "form version out of date",
+	"FromBBSAssignment":    "message not from jurisdiction's assigned BBS",
+	"FromBBSDown":          "message from incorrect BBS (simulated outage)",
+	"HandlingOrderCode":    "unknown handling order code",
+	"HandlingOrderMissing": "missing handling order code",
+	"MessageBeforeTrigger": "message sent before drill trigger",
+	"MessageCorrupt":       "message could not be parsed",
+	"MessageFromWinlink":   "message sent from Winlink",
+	"MessageNotASCII":      "message has non-ASCII characters",
+	"MessageNotPlainText":  "not a plain text message",
+	"MessageTooEarly":      "message sent outside of practice session",
+	"MessageTypeWrong":     "incorrect message type",
+	"ModelMismatch":        "message not transcribed correctly",
+	"MsgNumFormat":         "incorrect message number format",
+	"MsgNumPrefix":         "incorrect message number prefix",
+	"NoCallSign":           "no call sign in message",
+	"PIFOVersion":          "PackItForms version out of date",
+	"ReadReceipt":          "unexpected READ receipt message",
+	"ReplyDestination":     "reply not addressed to sender of exercise message",
+	"ReplyEmpty":           "reply has no content",
+	"ReplyReference":       "reply doesn't reference exercise message",
+	"ReplySlow":            "reply sent too long after exercise message",
+	"ResponseSlow":         "slow response to drill trigger",
+	"RouteWrong":           "message not routed via required BBS path",
+	"SubjectFormat":        "incorrect subject line format",
+	"SubjectHasSeverity":   "severity on subject line",
+	"SubjectPlainForm":     "form name in subject of non-form message",
+	"ToBBS":                "message to incorrect BBS",
+	"ToBBSDown":            "message to incorrect BBS (simulated outage)",
+}
+
+// ProblemLabel returns the human-readable summary for the specified problem
+// code.  Unknown codes are returned unchanged.
+func ProblemLabel(code string) string {
+	if label, ok := ProblemLabels[code]; ok {
+		return label
+	}
+	return code
+}
@@ -2,11 +2,14 @@ package analyze
 
 import (
 	"fmt"
+	"log"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/rothskeller/packet/xscmsg/delivrcpt"
 	"github.com/rothskeller/packet/xscmsg/readrcpt"
+	"github.com/rothskeller/wppsvr/config"
 	"github.com/rothskeller/wppsvr/store"
 )
 
@@ -26,20 +29,10 @@ func (a *Analysis) Responses(st astore) (list []*store.Response) {
 	default:
 		var dr delivrcpt.DeliveryReceipt
 		dr.MessageSubject = a.subject
-		dr.MessageTo = fmt.Sprintf("%s@%s.ampr.org", strings.ToLower(a.session.CallSign), strings.ToLower(a.sm.ToBBS))
+		dr.MessageTo = fmt.Sprintf("%s@%s.%s", strings.ToLower(a.session.CallSign), strings.ToLower(a.sm.ToBBS), bbsDomain(a.sm.ToBBS))
 		dr.DeliveredTime = now().Format("01/02/2006 15:04:05")
 		dr.LocalMessageID = a.sm.LocalID
-		switch a.sm.Score {
-		case 0:
-			dr.ExtraText = fmt.Sprintf("MESSAGE WAS NOT COUNTED as a check-in to the %s on %s.\nReason: %s\nFor more information, visit https://wpp.scc-ares-races.org",
-				a.session.Name, a.session.End.Format("January 2"), a.sm.Summary)
-		case 100:
-			dr.ExtraText = fmt.Sprintf("100%% correct check-in to the %s on %s.",
-				a.session.Name, a.session.End.Format("January 2"))
-		default:
-			dr.ExtraText = fmt.Sprintf("%d%% score for check-in to the %s on %s.\nReason: %s\nFor more information, visit https://wpp.scc-ares-races.org",
-				a.sm.Score, a.session.Name, a.session.End.Format("January 2"), a.sm.Summary)
-		}
+		dr.ExtraText = a.receiptText()
 		var r store.Response
 		r.LocalID = st.NextMessageID(a.session.Prefix)
 		r.ResponseTo = a.sm.LocalID
@@ -52,3 +45,116 @@ func (a *Analysis) Responses(st astore) (list []*store.Response) {
 	}
 	return list
 }
+
+// matchReceipt determines whether the message is a delivery or read receipt
+// for one of our responses, and if so, records which one.
+func (a *Analysis) matchReceipt(st astore) {
+	var subject, when string
+
+	switch msg := a.msg.(type) {
+	case *delivrcpt.DeliveryReceipt:
+		subject, when = msg.MessageSubject, msg.DeliveredTime
+	case *readrcpt.ReadReceipt:
+		subject, when, a.ackRead = msg.MessageSubject, msg.ReadTime, true
+	default:
+		return
+	}
+	mailbox, _, _ := strings.Cut(a.env.ReturnAddr, "@")
+	if subject == "" || mailbox == "" {
+		return
+	}
+	if a.ackResponse = st.FindResponse(subject, mailbox); a.ackResponse == "" {
+		return
+	}
+	if t, err := time.ParseInLocation("01/02/2006 15:04:05", when, time.Local); err == nil {
+		a.ackTime = t
+	} else {
+		a.ackTime = a.env.Date
+	}
+}
+
+// receiptVars are the variables available to receipt text templates.
+type receiptVars struct {
+	Score       int
+	Summary     string
+	SessionName string
+	SessionDate string
+	ServerURL   string
+	MessageLink string
+}
+
+// receiptText returns the extra text to be added to the delivery receipt for
+// the analyzed message.  It comes from the session's receipt text template if
+// it has one, or otherwise from the configured template for the message's
+// score.
+func (a *Analysis) receiptText() string {
+	var (
+		conf = config.Get()
+		vars = receiptVars{
+			Score:       a.sm.Score,
+			Summary:     a.sm.Summary,
+			SessionName: a.session.Name,
+			SessionDate: a.session.End.Format("January 2"),
+			ServerURL:   conf.ServerURL,
+			MessageLink: fmt.Sprintf("%s/message?hash=%s", conf.ServerURL, a.sm.Hash),
+		}
+		text string
+	)
+	switch {
+	case a.session.ReceiptText != "":
+		text = a.session.ReceiptText
+	case a.sm.Score == 0:
+		text = conf.ReceiptText.NotCounted
+	case a.sm.Score == 100:
+		text = conf.ReceiptText.Correct
+	default:
+		text = conf.ReceiptText.Partial
+	}
+	out, err := expandReceiptText(text, &vars)
+	if err == nil {
+		return out
+	}
+	log.Printf("ERROR: receipt text template for session %d: %s", a.session.ID, err)
+	// Fall back to the default for the message's score.
+	switch a.sm.Score {
+	case 0:
+		text = config.DefaultReceiptNotCounted
+	case 100:
+		text = config.DefaultReceiptCorrect
+	default:
+		text = config.DefaultReceiptPartial
+	}
+	out, _ = expandReceiptText(text, &vars)
+	return out
+}
+
+// expandReceiptText expands a receipt text template with the specified
+// variables.
+func expandReceiptText(text string, vars *receiptVars) (string, error) {
+	var sb strings.Builder
+
+	tmpl, err := template.New("receipt").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	if err = tmpl.Execute(&sb, vars); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// CheckReceiptText returns an error if the specified receipt text template
+// cannot be parsed or refers to unknown variables.  It is used by the session
+// editor.
+func CheckReceiptText(text string) error {
+	_, err := expandReceiptText(text, &receiptVars{})
+	return err
+}
+
+// bbsDomain returns the mail domain of the specified BBS.
+func bbsDomain(bbs string) string {
+	if bc := config.Get().BBSes[bbs]; bc != nil && bc.Domain != "" {
+		return bc.Domain
+	}
+	return "ampr.org"
+}
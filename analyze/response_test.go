@@ -0,0 +1,15 @@
+package analyze
+
+import "testing"
+
+func TestCheckReceiptText(t *testing.T) {
+	if err := CheckReceiptText("{{.Score}}% for {{.SessionName}}; see {{.MessageLink}}"); err != nil {
+		t.Errorf("valid template: %s", err)
+	}
+	if err := CheckReceiptText("{{.Score"); err == nil {
+		t.Error("unparseable template: no error")
+	}
+	if err := CheckReceiptText("{{.Grade}}"); err == nil {
+		t.Error("unknown variable: no error")
+	}
+}
@@ -0,0 +1,116 @@
+package analyze
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/rothskeller/wppsvr/config"
+)
+
+var (
+	// receivedHostRE extracts the host names from a Received header.  The
+	// host name is the first (or only) word after "from" or "by".
+	receivedHostRE = regexp.MustCompile(`(?i)\b(?:from|by)\s+([A-Z0-9][A-Z0-9-]*)`)
+	// forwardRE matches a BBS forwarding ("R:") line, and extracts the
+	// name of the BBS that added it.
+	forwardRE = regexp.MustCompile(`(?i)^R:\d{6}/\d{4}\S*\s.*?@:?([A-Z0-9]+)`)
+)
+
+// ForwardingPath returns the path a message took through the BBS network, as
+// a list of BBS names starting with the one it originated on (fromBBS) and
+// ending with the one it was received at (toBBS).  The path in between is
+// reconstructed from the forwarding trail that each BBS adds as the message
+// passes through it:  "R:" lines at the top of the message body, or failing
+// those, Received headers.  Only configured BBSes are included, and each only
+// once.
+func ForwardingPath(raw, fromBBS, toBBS string) (path []string) {
+	var (
+		bbses        = config.Get().BBSes
+		received     []string
+		forwards     []string
+		hops         []string
+		lastReceived bool
+	)
+	add := func(name string) {
+		name = strings.ToUpper(name)
+		if bbses[name] != nil && !slices.Contains(path, name) {
+			path = append(path, name)
+		}
+	}
+	header, body, _ := strings.Cut(strings.ReplaceAll(raw, "\r\n", "\n"), "\n\n")
+	for _, line := range strings.Split(header, "\n") {
+		switch {
+		case lastReceived && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")):
+			received[len(received)-1] += line
+		case len(line) > 9 && strings.EqualFold(line[:9], "Received:"):
+			received = append(received, line[9:])
+			lastReceived = true
+		default:
+			lastReceived = false
+		}
+	}
+	for _, line := range strings.Split(body, "\n") {
+		if match := forwardRE.FindStringSubmatch(line); match != nil {
+			forwards = append(forwards, match[1])
+		} else if strings.TrimSpace(line) != "" {
+			break
+		}
+	}
+	// Each BBS adds its forwarding line or header above the earlier ones,
+	// so we walk them in reverse order.  The "R:" lines are preferred when
+	// present, since they name each BBS explicitly.
+	if len(forwards) != 0 {
+		for i := len(forwards) - 1; i >= 0; i-- {
+			hops = append(hops, forwards[i])
+		}
+	} else {
+		for i := len(received) - 1; i >= 0; i-- {
+			for _, match := range receivedHostRE.FindAllStringSubmatch(received[i], -1) {
+				hops = append(hops, match[1])
+			}
+		}
+	}
+	add(fromBBS)
+	for _, hop := range hops {
+		if !strings.EqualFold(hop, toBBS) {
+			add(hop)
+		}
+	}
+	add(toBBS)
+	return path
+}
+
+// checkRoute verifies that the message was routed through the BBS path
+// required by the session:  that it originated on the first BBS of the path
+// and passed through the others, in order, on its way to the BBS where it was
+// received.
+func (a *Analysis) checkRoute() {
+	var (
+		path     = ForwardingPath(a.sm.Message, a.sm.FromBBS, a.sm.ToBBS)
+		required = append(slices.Clip(a.session.Route), a.sm.ToBBS)
+		next     int
+	)
+	a.outOf++
+	if len(path) != 0 && path[0] == required[0] {
+		for _, bbs := range path {
+			if next < len(required) && bbs == required[next] {
+				next++
+			}
+		}
+	}
+	if next == len(required) {
+		a.score++
+		return
+	}
+	a.setSummary("RouteWrong")
+	if len(path) == 0 {
+		fmt.Fprintf(a.analysis, "<h2>Message Not Routed as Required</h2><p>For %s, messages must originate on %s and travel the route %s.  This message did not originate on a BBS.</p>",
+			html.EscapeString(a.session.Name), required[0], strings.Join(required, " → "))
+	} else {
+		fmt.Fprintf(a.analysis, "<h2>Message Not Routed as Required</h2><p>For %s, messages must originate on %s and travel the route %s.  According to its forwarding headers, this message traveled the route %s.</p>",
+			html.EscapeString(a.session.Name), required[0], strings.Join(required, " → "), strings.Join(path, " → "))
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/rothskeller/packet/envelope"
+	"github.com/rothskeller/packet/message"
+	"github.com/rothskeller/packet/xscmsg/plaintext"
+	"github.com/rothskeller/wppsvr/config"
+	"github.com/rothskeller/wppsvr/english"
+	"github.com/rothskeller/wppsvr/retrieve"
+	"github.com/rothskeller/wppsvr/store"
+)
+
+// sendAnnouncements posts the announcements of running sessions to those of
+// their announcement addresses that haven't been sent one yet.  Announcements
+// that couldn't be posted are tried again next time, for as long as the session
+// is running.
+func sendAnnouncements(st *store.Store) {
+	for _, session := range st.GetRunningSessions() {
+		if len(session.AnnounceTo) == 0 {
+			continue
+		}
+		announceSession(st, session)
+	}
+}
+
+// announceSession posts the announcement of a session to each of its
+// announcement addresses (which may be bulletin addresses) that hasn't
+// successfully been sent one, through a connection to its first BBS.  The
+// outcome for each address is recorded so that it can be shown in the session
+// editor.
+func announceSession(st *store.Store, session *store.Session) {
+	var (
+		posted  = make(map[string]bool)
+		pending []string
+	)
+	for _, a := range st.GetAnnouncements(session.ID) {
+		posted[a.To] = a.Error == ""
+	}
+	for _, addr := range session.AnnounceTo {
+		if !posted[addr] {
+			pending = append(pending, addr)
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+	var (
+		body  = new(envelope.Envelope).RenderBody(announcementText(session))
+		conn  = retrieve.ConnectToBBS(session.ToBBSes[0], session.CallSign)
+		count int
+	)
+	for _, to := range pending {
+		var a = store.Announcement{Session: session.ID, To: to, Sent: time.Now()}
+
+		if conn == nil {
+			a.Error = fmt.Sprintf("could not connect to %s", session.ToBBSes[0])
+		} else {
+			subject := message.EncodeSubject(st.NextMessageID(session.Prefix), "ROUTINE", "", "Packet Practice: "+session.Name)
+			if err := conn.Send(subject, body, to); err != nil {
+				a.Error = err.Error()
+			} else {
+				count++
+			}
+		}
+		if a.Error != "" {
+			log.Printf("ERROR: announcing %s to %s: %s", session.Name, to, a.Error)
+		}
+		st.SaveAnnouncement(&a)
+	}
+	if conn != nil {
+		conn.Close()
+	}
+	if count != 0 {
+		log.Printf("Announced session for %s ending %s.", session.Name, session.End.Format("2006-01-02 15:04"))
+	}
+}
+
+// announcementText returns the plain text of the announcement of a session.
+// It gives the same information as the top of the session instructions page,
+// word-wrapped to stay within BBS line length limits.
+func announcementText(session *store.Session) string {
+	var (
+		sb strings.Builder
+		ww = english.NewWrapper(&sb)
+	)
+	fmt.Fprintf(ww, "The %s is now open.  Please send ", session.Name)
+	switch msg := session.ModelMsg.(type) {
+	case nil:
+		var article string
+		var names []string
+		for i, tag := range session.MessageTypes {
+			if mt := message.RegisteredTypes[tag]; mt != nil {
+				if i == 0 {
+					article = mt[0].Article
+				}
+				names = append(names, mt[0].Name)
+			} else {
+				if i == 0 {
+					article = "a"
+				}
+				names = append(names, tag)
+			}
+		}
+		fmt.Fprintf(ww, "%s %s", article, english.Conjoin(names, "or"))
+	case *plaintext.PlainText:
+		fmt.Fprintf(ww, "a plain text message with the subject %q", msg.Subject)
+	default:
+		fmt.Fprintf(ww, "a copy of the %s on the instructions page", msg.Base().Type.Name)
+	}
+	fmt.Fprintf(ww, " to %s at %s.  The message must be received there between %s and %s.",
+		session.CallSign, english.Conjoin(session.ToBBSes, "or"),
+		session.Start.Format("15:04 on Monday"), session.End.Format("15:04 on Monday, January 2"))
+	switch len(session.DownBBSes) {
+	case 0:
+		break
+	case 1:
+		fmt.Fprintf(ww, "  Do not use or send to %s during this session; it has a simulated outage.", session.DownBBSes[0])
+	default:
+		fmt.Fprintf(ww, "  Do not use or send to %s during this session; they have simulated outages.", english.Conjoin(session.DownBBSes, "or"))
+	}
+	if session.AnnounceText != "" {
+		fmt.Fprintf(ww, "\n\n%s", session.AnnounceText)
+	}
+	fmt.Fprintf(ww, "\n\nFull instructions are at %s/instructions?session=%d\n", config.Get().ServerURL, session.ID)
+	ww.Close()
+	return sb.String()
+}
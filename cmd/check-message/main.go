@@ -0,0 +1,250 @@
+// check-message analyzes a practice message offline, without a wppsvr.db
+// database or any network access, and prints the results.  It is intended to
+// help exercise designers check a model message, and the common mistakes in
+// it, before publishing a session.
+//
+// usage: check-message [-config config.yaml] test.yaml
+//
+//	check-message [-config config.yaml] session.yaml message.txt
+//
+// The first form takes a file in the same format as the analysis test cases
+// (see analyze/testdata), containing "config", "session", "toBBS", and
+// "message" keys.  The second form takes a file containing the session
+// definition (either bare or under a "session" key), and a separate file
+// containing the raw received message.  Session fields that aren't specified
+// get the same defaults that the analysis tests use.
+//
+// The configuration is read from config.yaml in the current directory, or
+// from the file named with the -config flag.  Any "config" key in the YAML file
+// overrides it.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/rothskeller/packet/envelope"
+	"github.com/rothskeller/packet/message"
+	"github.com/rothskeller/packet/xscmsg"
+	"github.com/rothskeller/wppsvr/analyze"
+	"github.com/rothskeller/wppsvr/config"
+	"github.com/rothskeller/wppsvr/english"
+	"github.com/rothskeller/wppsvr/store"
+)
+
+// input is the structure of the YAML file.  It is a subset of the analysis
+// test case structure; other keys of test cases are ignored.
+type input struct {
+	Config  *config.Config `yaml:"config"`
+	Session *store.Session `yaml:"session"`
+	ToBBS   string         `yaml:"toBBS"`
+	Message string         `yaml:"message"`
+	// Exercise is the exercise message that the store has on file, for a
+	// two-way exercise session.
+	Exercise *store.ExerciseMessage `yaml:"exercise"`
+}
+
+func main() {
+	var (
+		configFile string
+		in         input
+		fs         = &fakeStore{nextID: 100}
+	)
+	flag.StringVar(&configFile, "config", "config.yaml", "configuration file")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: check-message [-config config.yaml] test.yaml\n       check-message [-config config.yaml] session.yaml message.txt\n")
+	}
+	flag.Parse()
+	if flag.NArg() < 1 || flag.NArg() > 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	xscmsg.Register()
+	if err := config.ReadFrom(configFile); err != nil {
+		os.Exit(1)
+	}
+	if err := readInput(&in, flag.Arg(0), flag.Arg(1)); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+		os.Exit(1)
+	}
+	if in.Message == "" {
+		fmt.Fprintf(os.Stderr, "ERROR: no message to analyze\n")
+		os.Exit(1)
+	}
+	if in.Session.ModelMessage != "" {
+		env, body, err := envelope.ParseSaved(in.Session.ModelMessage)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: model message: %s\n", err)
+			os.Exit(1)
+		}
+		in.Session.ModelMsg = message.Decode(env, body)
+	}
+	if in.ToBBS == "" && len(in.Session.ToBBSes) != 0 {
+		in.ToBBS = in.Session.ToBBSes[0]
+	}
+	// Run the analysis.  We don't want the analyzer's logging mixed in
+	// with our output, and we don't want it making network requests to
+	// look up jurisdictions.
+	log.SetOutput(io.Discard)
+	analyze.LookupJurisdiction = nil
+	fs.exercise = in.Exercise
+	analysis := analyze.Analyze(fs, in.Session, in.ToBBS, in.Message)
+	responses := analysis.Responses(fs)
+	log.SetOutput(os.Stderr)
+	printAnalysis(analysis.Record())
+	for _, r := range responses {
+		fmt.Printf("---- RESPONSE\nTo: %s\nSubject: %s\n\n%s\n", r.To, r.Subject, r.Body)
+	}
+}
+
+// readInput reads the YAML file, and the message file if any, into in.
+func readInput(in *input, yamlFile, messageFile string) (err error) {
+	var (
+		doc  yaml.Node
+		data []byte
+	)
+	// Decode any configuration overrides into a copy of the global
+	// configuration, not into the global configuration itself.
+	c := *config.Get()
+	in.Config = &c
+	in.Session = &store.Session{
+		ID:           42,
+		CallSign:     "PKTTUE",
+		Name:         "SVECS Net",
+		Prefix:       "TUE",
+		Start:        time.Date(2022, 1, 5, 0, 0, 0, 0, time.Local),
+		End:          time.Date(2022, 1, 11, 20, 0, 0, 0, time.Local),
+		ToBBSes:      []string{"W4XSC"},
+		DownBBSes:    []string{"W2XSC"},
+		MessageTypes: []string{"plain"},
+	}
+	if data, err = os.ReadFile(yamlFile); err != nil {
+		return err
+	}
+	if err = yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("%s: %s", yamlFile, err)
+	}
+	if hasKey(&doc, "session") || hasKey(&doc, "message") {
+		err = doc.Decode(in)
+	} else {
+		err = doc.Decode(in.Session)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %s", yamlFile, err)
+	}
+	if !in.Config.Validate() {
+		return fmt.Errorf("%s: invalid configuration data", yamlFile)
+	}
+	config.SetConfig(in.Config)
+	if in.Session.Exercise != "" {
+		env, body, err := envelope.ParseSaved(in.Session.Exercise)
+		if err != nil {
+			return fmt.Errorf("%s: exercise message: %s", yamlFile, err)
+		}
+		in.Session.ExerciseMsg = message.Decode(env, body)
+	}
+	if messageFile != "" {
+		if data, err = os.ReadFile(messageFile); err != nil {
+			return err
+		}
+		in.Message = string(data)
+	}
+	return nil
+}
+
+// hasKey returns whether the top level of the YAML document is a mapping
+// containing the specified key.
+func hasKey(doc *yaml.Node, key string) bool {
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return false
+	}
+	for i := 0; i < len(doc.Content[0].Content); i += 2 {
+		if doc.Content[0].Content[i].Value == key {
+			return true
+		}
+	}
+	return false
+}
+
+// printAnalysis prints the results of the analysis.
+func printAnalysis(m *store.Message) {
+	if m == nil {
+		fmt.Println("Message was not analyzed.")
+		return
+	}
+	fmt.Printf("Message ID:  %s\nFrom:        %s\nType:        %s\nScore:       %d%%\nSummary:     %s\n",
+		m.LocalID, m.FromAddress, m.MessageType, m.Score, m.Summary)
+	if len(m.Problems) != 0 {
+		fmt.Printf("Problems:    %s\n", strings.Join(m.Problems, ", "))
+	}
+	fmt.Println()
+	if m.Analysis != "" {
+		fmt.Println("---- ANALYSIS")
+		wr := english.NewWrapper(os.Stdout)
+		wr.WriteString(htmlToText(m.Analysis))
+		wr.Close()
+		fmt.Println()
+	}
+}
+
+var (
+	blockTagRE = regexp.MustCompile(`(?i)</?(?:h2|p|div|ul|table|tr)(?:\s[^>]*)?>|<br\s*/?>`)
+	itemTagRE  = regexp.MustCompile(`(?i)<li(?:\s[^>]*)?>`)
+	headingRE  = regexp.MustCompile(`(?is)<h2(?:\s[^>]*)?>(.*?)</h2>`)
+	anyTagRE   = regexp.MustCompile(`<[^>]*>`)
+	blankRE    = regexp.MustCompile(`\n\s*\n(?:\s*\n)*`)
+)
+
+// htmlToText converts the HTML analysis of a message into plain text, with
+// paragraphs separated by blank lines.
+func htmlToText(s string) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	s = headingRE.ReplaceAllStringFunc(s, func(h string) string {
+		h = anyTagRE.ReplaceAllString(headingRE.FindStringSubmatch(h)[1], "")
+		return "\n\n" + strings.ToUpper(html.UnescapeString(h)) + "\n\n"
+	})
+	s = itemTagRE.ReplaceAllString(s, "\n\n  - ")
+	s = blockTagRE.ReplaceAllString(s, "\n\n")
+	s = anyTagRE.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	s = blankRE.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s) + "\n"
+}
+
+// fakeStore is the store given to the analyzer.  It never has the message
+// already, assigns local message IDs without a database, and discards
+// anything saved.  The only exercise message it knows of is the one given in
+// the input, if any.
+type fakeStore struct {
+	nextID   int
+	exercise *store.ExerciseMessage
+}
+
+func (f *fakeStore) HasMessageHash(string) string { return "" }
+
+func (f *fakeStore) NextMessageID(prefix string) string {
+	f.nextID++
+	return fmt.Sprintf("%s-%03dP", prefix, f.nextID-1)
+}
+
+func (f *fakeStore) SaveMessage(*store.Message) {}
+
+func (f *fakeStore) FindResponse(string, string) string { return "" }
+
+func (f *fakeStore) AcknowledgeResponse(string, bool, time.Time) {}
+
+func (f *fakeStore) GetExerciseMessage(session int, msgid string) *store.ExerciseMessage {
+	if f.exercise != nil && f.exercise.Session == session && f.exercise.MessageID == msgid {
+		return f.exercise
+	}
+	return nil
+}
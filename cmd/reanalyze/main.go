@@ -76,3 +76,12 @@ func (fs *filteredStore) NextMessageID(string) string {
 func (fs *filteredStore) SaveMessage(m *store.Message) {
 	fs.st.SaveMessage(m)
 }
+func (fs *filteredStore) FindResponse(subject, mailbox string) string {
+	return fs.st.FindResponse(subject, mailbox)
+}
+func (fs *filteredStore) AcknowledgeResponse(id string, read bool, at time.Time) {
+	fs.st.AcknowledgeResponse(id, read, at)
+}
+func (fs *filteredStore) GetExerciseMessage(session int, msgid string) *store.ExerciseMessage {
+	return fs.st.GetExerciseMessage(session, msgid)
+}
@@ -1,10 +1,12 @@
 // send-report sends the report for the specified session to the specified
-// email addresses.
+// email addresses.  With the -attach flag, the report is also attached to the
+// email in CSV, JSON, and PDF formats.
 //
-// usage: send-report session-date email-address...
+// usage: send-report [-attach] session-date email-address...
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -23,15 +25,21 @@ func main() {
 		sessions []*store.Session
 		session  *store.Session
 		rep      *report.Report
+		attach   bool
 		err      error
 	)
-	if len(os.Args) < 3 {
-		fmt.Fprintf(os.Stderr, "usage: send-report session-date email-address...\n")
+	flag.BoolVar(&attach, "attach", false, "attach CSV, JSON, and PDF renderings of the report")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: send-report [-attach] session-date email-address...\n")
+	}
+	flag.Parse()
+	if flag.NArg() < 2 {
+		flag.Usage()
 		os.Exit(2)
 	}
-	if date, err = time.Parse("2006-01-02", os.Args[1]); err != nil {
-		fmt.Fprintf(os.Stderr, "ERROR: %q is not a date\n", os.Args[1])
-		fmt.Fprintf(os.Stderr, "usage: send-report session-date email-address...\n")
+	if date, err = time.Parse("2006-01-02", flag.Arg(0)); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %q is not a date\n", flag.Arg(0))
+		flag.Usage()
 		os.Exit(2)
 	}
 	xscmsg.Register()
@@ -47,12 +55,12 @@ func main() {
 	)
 	switch len(sessions) {
 	case 0:
-		fmt.Fprintf(os.Stderr, "ERROR: no sessions on %s\n", os.Args[1])
+		fmt.Fprintf(os.Stderr, "ERROR: no sessions on %s\n", flag.Arg(0))
 		os.Exit(1)
 	case 1:
 		session = sessions[0]
 	default:
-		fmt.Fprintf(os.Stderr, "ERROR: multiple sessions on %s\n", os.Args[1])
+		fmt.Fprintf(os.Stderr, "ERROR: multiple sessions on %s\n", flag.Arg(0))
 		os.Exit(1)
 	}
 	if session == nil {
@@ -60,7 +68,7 @@ func main() {
 		os.Exit(2)
 	}
 	rep = report.Generate(st, session)
-	if err := rep.SendHTML(os.Args[2:]); err != nil {
+	if err := rep.SendHTML(flag.Args()[1:], attach); err != nil {
 		fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
 		os.Exit(1)
 	}
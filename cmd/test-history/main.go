@@ -0,0 +1,165 @@
+// test-history re-analyzes a set of past messages and ensures that their
+// analysis did not change.  It is used in testing wppsvr changes.  Nothing is
+// written to the database, and no responses are sent.
+//
+// usage: test-history session-selector...
+//
+// Each session selector can be a session end date (2006-01-02), a range of
+// session end dates (2006-01-02..2006-12-31), or a session ID number.  The
+// command exits with status 1 if the analysis of any message changed.
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rothskeller/packet/xscmsg"
+	"github.com/rothskeller/wppsvr/analyze"
+	"github.com/rothskeller/wppsvr/config"
+	"github.com/rothskeller/wppsvr/store"
+)
+
+const usage = "usage: test-history session-selector...\n  where session-selector is date, date..date, or session ID\n"
+
+func main() {
+	var (
+		st       *store.Store
+		sessions []*store.Session
+		checked  int
+		changed  int
+		err      error
+	)
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	xscmsg.Register()
+	if err = config.Read(); err != nil {
+		log.Fatal(err)
+	}
+	if st, err = store.Open(); err != nil {
+		log.Fatal(err)
+	}
+	for _, arg := range os.Args[1:] {
+		found, err := selectSessions(st, arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+			fmt.Fprint(os.Stderr, usage)
+			os.Exit(2)
+		}
+		sessions = append(sessions, found...)
+	}
+	// The analyzer logs every message it sees; we don't want that noise
+	// in our report.
+	log.SetOutput(io.Discard)
+	for _, session := range sessions {
+		for _, old := range st.GetSessionMessages(session.ID) {
+			var fs = &readOnlyStore{st: st, id: old.LocalID}
+
+			// Rather than looking up the sender's jurisdiction
+			// over the network, use the one recorded originally.
+			analyze.LookupJurisdiction = func(string) string { return old.Jurisdiction }
+			checked++
+			analysis := analyze.Analyze(fs, session, old.ToBBS, old.Message)
+			if diffs := compare(old, analysis.Record()); len(diffs) != 0 {
+				changed++
+				fmt.Printf("%s from %s (%s %s):\n", old.LocalID, old.FromAddress,
+					session.End.Format("2006-01-02"), session.Name)
+				for _, diff := range diffs {
+					fmt.Printf("    %s\n", diff)
+				}
+			}
+		}
+	}
+	fmt.Printf("%d messages in %d sessions checked, %d changed.\n", checked, len(sessions), changed)
+	if changed != 0 {
+		os.Exit(1)
+	}
+}
+
+// selectSessions returns the sessions identified by a single command line
+// argument.
+func selectSessions(st *store.Store, arg string) (sessions []*store.Session, err error) {
+	var start, end time.Time
+
+	if id, err := strconv.Atoi(arg); err == nil {
+		if session := st.GetSession(id); session != nil {
+			return []*store.Session{session}, nil
+		}
+		return nil, fmt.Errorf("no session with ID %d", id)
+	}
+	if from, to, ok := strings.Cut(arg, ".."); ok {
+		if start, err = time.ParseInLocation("2006-01-02", from, time.Local); err != nil {
+			return nil, fmt.Errorf("%q is not a date", from)
+		}
+		if end, err = time.ParseInLocation("2006-01-02", to, time.Local); err != nil {
+			return nil, fmt.Errorf("%q is not a date", to)
+		}
+	} else {
+		if start, err = time.ParseInLocation("2006-01-02", arg, time.Local); err != nil {
+			return nil, fmt.Errorf("%q is not a date, date range, or session ID", arg)
+		}
+		end = start
+	}
+	if sessions = st.GetSessions(start, end.AddDate(0, 0, 1)); len(sessions) == 0 {
+		return nil, fmt.Errorf("no sessions found for %s", arg)
+	}
+	return sessions, nil
+}
+
+// compare returns a list of the differences between the stored analysis of a
+// message and its new analysis.
+func compare(old, cur *store.Message) (diffs []string) {
+	if cur == nil {
+		return []string{"message was not analyzed"}
+	}
+	if old.Score != cur.Score {
+		diffs = append(diffs, fmt.Sprintf("score:    %d => %d", old.Score, cur.Score))
+	}
+	if old.Summary != cur.Summary {
+		diffs = append(diffs, fmt.Sprintf("summary:  %q => %q", old.Summary, cur.Summary))
+	}
+	if old.MessageType != cur.MessageType {
+		diffs = append(diffs, fmt.Sprintf("type:     %s => %s", old.MessageType, cur.MessageType))
+	}
+	// Messages analyzed before problem codes were recorded have a summary
+	// but no problem codes.  We can't compare those.
+	if (len(old.Problems) != 0 || old.Summary == "" || old.Summary == "OK") && !slices.Equal(old.Problems, cur.Problems) {
+		diffs = append(diffs, fmt.Sprintf("problems: [%s] => [%s]",
+			strings.Join(old.Problems, " "), strings.Join(cur.Problems, " ")))
+	}
+	return diffs
+}
+
+// readOnlyStore is the store given to the analyzer.  It keeps the analyzer
+// from seeing the message as a duplicate, gives it back the message's original
+// ID, and never writes anything.
+type readOnlyStore struct {
+	st *store.Store
+	id string
+}
+
+func (rs *readOnlyStore) HasMessageHash(string) string {
+	return "" // we never already have the message.
+}
+func (rs *readOnlyStore) NextMessageID(string) string {
+	return rs.id // return the ID that the message was given originally
+}
+func (rs *readOnlyStore) SaveMessage(*store.Message) {
+	panic("test-history must not save messages")
+}
+func (rs *readOnlyStore) FindResponse(subject, mailbox string) string {
+	return rs.st.FindResponse(subject, mailbox)
+}
+func (rs *readOnlyStore) AcknowledgeResponse(string, bool, time.Time) {
+	panic("test-history must not acknowledge responses")
+}
+func (rs *readOnlyStore) GetExerciseMessage(session int, msgid string) *store.ExerciseMessage {
+	return rs.st.GetExerciseMessage(session, msgid)
+}
@@ -21,6 +21,29 @@ type Config struct {
 	SMTP            *SMTPConfig                   `yaml:"smtp"`
 	CanViewEveryone []string                      `yaml:"canViewEveryone"`
 	CanEditSessions []string                      `yaml:"canEditSessions"`
+	Comparison      map[string]*ComparisonConfig  `yaml:"comparison"`
+	BBSAssignments  map[string]*BBSAssignment     `yaml:"bbsAssignments"`
+	ReceiptText     ReceiptTextConfig             `yaml:"receiptText"`
+	SummaryReports  SummaryReportsConfig          `yaml:"summaryReports"`
+	// JurisdictionReports maps jurisdiction codes to the email addresses
+	// to which jurisdiction-scoped session reports should be sent.
+	JurisdictionReports map[string][]string `yaml:"jurisdictionReports"`
+	MissedYou           MissedYouConfig     `yaml:"missedYou"`
+	// ReminderHours is the number of hours before the end of a session
+	// at which regular participants who haven't checked in yet are sent
+	// a reminder.  Zero means reminders are not sent.
+	ReminderHours int         `yaml:"reminderHours"`
+	Drill         DrillConfig `yaml:"drill"`
+	// ReplyHours is the number of hours after the exercise message of a
+	// two-way exercise session is sent within which a reply gets credit
+	// for a timely turnaround.
+	ReplyHours int `yaml:"replyHours"`
+	// ModelPools gives the content from which model messages for form
+	// practice sessions are generated.  It maps form type tags to maps
+	// from field labels to lists of values, one of which is chosen at
+	// random for the field of each generated message.  The pools under
+	// the "*" tag apply to all form types.
+	ModelPools map[string]map[string][]string `yaml:"modelPools"`
 }
 
 // An SMTPConfig describes how to send email via SMTP.
@@ -40,8 +63,102 @@ type MessageTypeConfig struct {
 	ToLocation     []string `yaml:"toLocation"`
 }
 
-// BBSConfig holds the configuration of a single BBS.
+// A ComparisonConfig structure specifies how a field of a received message is
+// compared against the corresponding field of the session's model message.
+// Normalize is a list of normalizations (whitespace, case, punctuation, date)
+// applied to both values before they are compared.  PartialCredit, if nonzero,
+// is the minimum similarity percentage (based on edit distance) at which a
+// mismatched field gets partial credit.
+type ComparisonConfig struct {
+	Normalize     []string `yaml:"normalize"`
+	PartialCredit int      `yaml:"partialCredit"`
+}
+
+// A BBSAssignment gives the BBSes that a jurisdiction is assigned to use, per
+// the City/Agency BBS Assignments page.  Alternate is used when Primary has a
+// (simulated) outage.
+type BBSAssignment struct {
+	Primary   string `yaml:"primary"`
+	Alternate string `yaml:"alternate"`
+}
+
+// ReceiptTextConfig gives the text/template strings for the text added to
+// delivery receipts, for messages that were not counted (score 0), were
+// entirely correct (score 100), or were counted with problems (any other
+// score).  The templates can use the variables .Score, .Summary, .SessionName,
+// .SessionDate, .ServerURL, and .MessageLink.  Any that are not specified get
+// defaults.
+type ReceiptTextConfig struct {
+	NotCounted string `yaml:"notCounted"`
+	Correct    string `yaml:"correct"`
+	Partial    string `yaml:"partial"`
+}
+
+// Default receipt text templates.
+const (
+	DefaultReceiptNotCounted = "MESSAGE WAS NOT COUNTED as a check-in to the {{.SessionName}} on {{.SessionDate}}.\nReason: {{.Summary}}\nFor more information, visit {{.ServerURL}}"
+	DefaultReceiptCorrect    = "100% correct check-in to the {{.SessionName}} on {{.SessionDate}}."
+	DefaultReceiptPartial    = "{{.Score}}% score for check-in to the {{.SessionName}} on {{.SessionDate}}.\nReason: {{.Summary}}\nFor more information, visit {{.ServerURL}}"
+)
+
+// SummaryReportsConfig gives the email addresses to which summary reports are
+// sent automatically at the end of each month and each year.
+type SummaryReportsConfig struct {
+	Monthly []string `yaml:"monthly"`
+	Yearly  []string `yaml:"yearly"`
+}
+
+// MissedYouConfig defines the regular participants of a net:  those who checked
+// in to at least Regular of its last Sessions sessions.  Regulars who miss a
+// session are listed in its report, and if the session asks for it, are sent
+// the Message text/template string, which can use the variables .CallSign,
+// .SessionName, .SessionDate, and .ServerURL.  Any that are not specified get
+// defaults.
+type MissedYouConfig struct {
+	Sessions int    `yaml:"sessions"`
+	Regular  int    `yaml:"regular"`
+	Message  string `yaml:"message"`
+}
+
+// Defaults for MissedYouConfig.
+const (
+	DefaultMissedYouSessions = 8
+	DefaultMissedYouRegular  = 6
+	DefaultMissedYouMessage  = "We missed you at the {{.SessionName}} on {{.SessionDate}}!  You've been a regular participant, and we hope to hear from you next time.\n\nFor the schedule of upcoming practice sessions, visit {{.ServerURL}}"
+)
+
+// DrillConfig gives the scoring of check-ins to timed drill sessions, based on
+// how long after the trigger message they arrive at the BBS.  A check-in that
+// arrives within the Minutes of a bracket (and not within those of an earlier
+// one) gets the Score percentage of the credit it would otherwise get; one that
+// arrives after all of the brackets gets LateScore percent.  The brackets must
+// be in increasing order of Minutes.  If no brackets are specified, defaults
+// are used.
+type DrillConfig struct {
+	Brackets  []*DrillBracket `yaml:"brackets"`
+	LateScore int             `yaml:"lateScore"`
+}
+
+// A DrillBracket is a single response time bracket for timed drill sessions.
+type DrillBracket struct {
+	Minutes int `yaml:"minutes"`
+	Score   int `yaml:"score"`
+}
+
+// Defaults for DrillConfig.
+var DefaultDrillBrackets = []*DrillBracket{
+	{Minutes: 15, Score: 100}, {Minutes: 30, Score: 90}, {Minutes: 60, Score: 75}, {Minutes: 120, Score: 50},
+}
+
+const DefaultDrillLateScore = 25
+
+// DefaultReplyHours is the default value of ReplyHours.
+const DefaultReplyHours = 24
+
+// BBSConfig holds the configuration of a single BBS.  Domain is the mail
+// domain of the BBS, after its call sign; it defaults to "ampr.org".
 type BBSConfig struct {
+	Domain    string            `yaml:"domain"`
 	Transport string            `yaml:"transport"`
 	AX25      string            `yaml:"ax25"`
 	TCP       string            `yaml:"tcp"`
@@ -64,12 +181,19 @@ func Get() *Config {
 // Read reads the system configuration from the config.yaml file.  If an error
 // occurs, the previous configuration is retained and the error is returned.
 func Read() (err error) {
+	return ReadFrom("config.yaml")
+}
+
+// ReadFrom reads the system configuration from the specified file.  If an
+// error occurs, the previous configuration is retained and the error is
+// returned.
+func ReadFrom(filename string) (err error) {
 	var (
 		newconfig Config
 		configFH  *os.File
 		decoder   *yaml.Decoder
 	)
-	if configFH, err = os.Open("config.yaml"); err != nil {
+	if configFH, err = os.Open(filename); err != nil {
 		log.Printf("ERROR: opening config file: %s", err)
 		return err
 	}
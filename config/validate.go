@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
+	"text/template"
 
 	"github.com/rothskeller/packet/message"
 )
@@ -29,6 +30,11 @@ func (c *Config) Validate() (valid bool) {
 			log.Printf("ERROR: config.bbses: %q is not a valid FCC call sign", bbsCall)
 			valid = false
 		}
+		if bbsConf.Domain == "" {
+			bbsConf.Domain = "ampr.org"
+		} else {
+			bbsConf.Domain = strings.ToLower(strings.Trim(bbsConf.Domain, "."))
+		}
 		switch bbsConf.Transport {
 		case "":
 			log.Printf("ERROR: config.bbses[%q].transport is not specified", bbsCall)
@@ -114,6 +120,171 @@ func (c *Config) Validate() (valid bool) {
 		}
 	}
 
+	// Check the model comparison rules.
+	for label, cc := range c.Comparison {
+		if cc == nil {
+			log.Printf("ERROR: config.comparison[%q] is empty", label)
+			valid = false
+			continue
+		}
+		for _, norm := range cc.Normalize {
+			switch norm {
+			case "whitespace", "case", "punctuation", "date":
+				// nothing
+			default:
+				log.Printf("ERROR: config.comparison[%q].normalize: %q is not a known normalization", label, norm)
+				valid = false
+			}
+		}
+		if cc.PartialCredit < 0 || cc.PartialCredit > 100 {
+			log.Printf("ERROR: config.comparison[%q].partialCredit = %d is not a percentage", label, cc.PartialCredit)
+			valid = false
+		}
+	}
+
+	// Check the BBS assignments.
+	for juris, assign := range c.BBSAssignments {
+		if len(juris) != 3 || strings.ToUpper(juris) != juris {
+			log.Printf("ERROR: config.bbsAssignments: %q is not a valid jurisdiction code", juris)
+			valid = false
+		}
+		if assign == nil || assign.Primary == "" {
+			log.Printf("ERROR: config.bbsAssignments[%q].primary is not specified", juris)
+			valid = false
+			continue
+		}
+		if c.BBSes[assign.Primary] == nil {
+			log.Printf("ERROR: config.bbsAssignments[%q].primary = %q is not a configured BBS", juris, assign.Primary)
+			valid = false
+		}
+		if assign.Alternate != "" && c.BBSes[assign.Alternate] == nil {
+			log.Printf("ERROR: config.bbsAssignments[%q].alternate = %q is not a configured BBS", juris, assign.Alternate)
+			valid = false
+		}
+	}
+
+	// Check the receipt text templates.
+	for _, rt := range []struct {
+		name string
+		tmpl *string
+		def  string
+	}{
+		{"notCounted", &c.ReceiptText.NotCounted, DefaultReceiptNotCounted},
+		{"correct", &c.ReceiptText.Correct, DefaultReceiptCorrect},
+		{"partial", &c.ReceiptText.Partial, DefaultReceiptPartial},
+	} {
+		if *rt.tmpl == "" {
+			*rt.tmpl = rt.def
+		} else if _, err := template.New(rt.name).Parse(*rt.tmpl); err != nil {
+			log.Printf("ERROR: config.receiptText.%s: %s", rt.name, err)
+			valid = false
+		}
+	}
+
+	// Check the missed-you configuration.
+	if c.MissedYou.Sessions == 0 {
+		c.MissedYou.Sessions = DefaultMissedYouSessions
+	}
+	if c.MissedYou.Regular == 0 {
+		c.MissedYou.Regular = min(DefaultMissedYouRegular, c.MissedYou.Sessions)
+	}
+	if c.MissedYou.Sessions < 0 || c.MissedYou.Regular < 0 || c.MissedYou.Regular > c.MissedYou.Sessions {
+		log.Printf("ERROR: config.missedYou: regular must be between 1 and sessions")
+		valid = false
+	}
+	if c.MissedYou.Message == "" {
+		c.MissedYou.Message = DefaultMissedYouMessage
+	} else if _, err := template.New("missedYou").Parse(c.MissedYou.Message); err != nil {
+		log.Printf("ERROR: config.missedYou.message: %s", err)
+		valid = false
+	}
+
+	// Check the reminder time.
+	if c.ReminderHours < 0 {
+		log.Printf("ERROR: config.reminderHours must not be negative")
+		valid = false
+	}
+
+	// Check the drill scoring brackets.
+	if len(c.Drill.Brackets) == 0 {
+		c.Drill.Brackets = DefaultDrillBrackets
+	}
+	for i, b := range c.Drill.Brackets {
+		if b.Minutes <= 0 || (i > 0 && b.Minutes <= c.Drill.Brackets[i-1].Minutes) {
+			log.Printf("ERROR: config.drill.brackets[%d].minutes must be positive and greater than in the previous bracket", i)
+			valid = false
+		}
+		if b.Score < 1 || b.Score > 100 {
+			log.Printf("ERROR: config.drill.brackets[%d].score must be between 1 and 100", i)
+			valid = false
+		}
+	}
+	if c.Drill.LateScore == 0 {
+		c.Drill.LateScore = DefaultDrillLateScore
+	} else if c.Drill.LateScore < 0 || c.Drill.LateScore > 100 {
+		log.Printf("ERROR: config.drill.lateScore must be between 1 and 100")
+		valid = false
+	}
+
+	// Check the two-way exercise reply time.
+	if c.ReplyHours == 0 {
+		c.ReplyHours = DefaultReplyHours
+	} else if c.ReplyHours < 0 {
+		log.Printf("ERROR: config.replyHours must not be negative")
+		valid = false
+	}
+
+	// Check the model message generator content pools.
+	for tag, pools := range c.ModelPools {
+		if _, ok := message.RegisteredTypes[tag]; !ok && tag != "*" {
+			log.Printf("ERROR: config.modelPools has entry for unknown message type %q", tag)
+			valid = false
+		} else if tag == "plain" {
+			log.Printf("ERROR: config.modelPools[%q]: model messages can be generated only for forms", tag)
+			valid = false
+		}
+		labels := formFieldLabels(tag)
+		for label, values := range pools {
+			if len(values) == 0 {
+				log.Printf("ERROR: config.modelPools[%q][%q] is empty", tag, label)
+				valid = false
+			}
+			if labels != nil && !labels[label] {
+				log.Printf("ERROR: config.modelPools[%q][%q]: no such field", tag, label)
+				valid = false
+			}
+		}
+	}
+
+	// Check the summary report recipients.
+	for _, sr := range []struct {
+		name string
+		to   []string
+	}{{"monthly", c.SummaryReports.Monthly}, {"yearly", c.SummaryReports.Yearly}} {
+		for i, addr := range sr.to {
+			if _, err := mail.ParseAddress(addr); err != nil {
+				log.Printf("ERROR: config.summaryReports.%s[%d] = %q: not a valid email address", sr.name, i, addr)
+				valid = false
+			}
+			haveHTMLReports = true
+		}
+	}
+
+	// Check the jurisdiction report recipients.
+	for juris, to := range c.JurisdictionReports {
+		if len(juris) != 3 || strings.ToUpper(juris) != juris {
+			log.Printf("ERROR: config.jurisdictionReports: %q is not a valid jurisdiction code", juris)
+			valid = false
+		}
+		for i, addr := range to {
+			if _, err := mail.ParseAddress(addr); err != nil {
+				log.Printf("ERROR: config.jurisdictionReports[%q][%d] = %q: not a valid email address", juris, i, addr)
+				valid = false
+			}
+			haveHTMLReports = true
+		}
+	}
+
 	// Check that we have a URL for the web server.
 	if c.ServerURL == "" {
 		log.Printf("ERROR: config.serverURL is not specified")
@@ -177,3 +348,23 @@ func (c *Config) Validate() (valid bool) {
 	}
 	return valid
 }
+
+// formFieldLabels returns the set of field labels of the current version of the
+// form type with the specified tag, or of any form type if the tag is "*".  It
+// returns nil if there is no such form type.
+func formFieldLabels(tag string) (labels map[string]bool) {
+	for t, mtypes := range message.RegisteredTypes {
+		if (tag != "*" && t != tag) || t == "plain" || len(mtypes) == 0 {
+			continue
+		}
+		if msg := message.Create(t, mtypes[0].Version); msg != nil {
+			if labels == nil {
+				labels = make(map[string]bool)
+			}
+			for _, f := range msg.Base().Fields {
+				labels[f.Label] = true
+			}
+		}
+	}
+	return labels
+}
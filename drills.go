@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/rothskeller/packet/envelope"
+	"github.com/rothskeller/packet/message"
+	"github.com/rothskeller/wppsvr/config"
+	"github.com/rothskeller/wppsvr/english"
+	"github.com/rothskeller/wppsvr/retrieve"
+	"github.com/rothskeller/wppsvr/store"
+)
+
+// sendDrillTriggers posts the trigger messages of running timed drill sessions
+// whose trigger times have arrived.
+func sendDrillTriggers(st *store.Store) {
+	var now = time.Now()
+
+	for _, session := range st.GetRunningSessions() {
+		if session.TriggerAt.IsZero() || !session.TriggerSent.IsZero() || now.Before(session.TriggerAt) {
+			continue
+		}
+		sendDrillTrigger(st, session)
+	}
+}
+
+// sendDrillTrigger posts the trigger message of a timed drill session to each
+// of its trigger addresses (which may be bulletin addresses), through a
+// connection to its first BBS.  Response times are measured from the time it
+// is sent, which is recorded in the session once it has been sent to at least
+// one address.  If it couldn't be sent anywhere, it is tried again next time.
+func sendDrillTrigger(st *store.Store, session *store.Session) {
+	var (
+		body = new(envelope.Envelope).RenderBody(triggerText(session))
+		conn = retrieve.ConnectToBBS(session.ToBBSes[0], session.CallSign)
+	)
+	if conn == nil {
+		return // try again next time
+	}
+	defer conn.Close()
+	for _, to := range session.TriggerTo {
+		subject := message.EncodeSubject(st.NextMessageID(session.Prefix), "IMMEDIATE", "", "DRILL: "+session.Name)
+		if err := conn.Send(subject, body, to); err != nil {
+			log.Printf("ERROR: sending drill trigger for %s to %s: %s", session.Name, to, err)
+		} else if session.TriggerSent.IsZero() {
+			session.TriggerSent = time.Now()
+		}
+	}
+	if session.TriggerSent.IsZero() {
+		return // try again next time
+	}
+	st.UpdateSession(session)
+	log.Printf("Sent drill trigger for %s ending %s.", session.Name, session.End.Format("2006-01-02 15:04"))
+}
+
+// triggerText returns the plain text of the trigger message of a timed drill
+// session, word-wrapped to stay within BBS line length limits.
+func triggerText(session *store.Session) string {
+	var (
+		sb strings.Builder
+		ww = english.NewWrapper(&sb)
+	)
+	if session.TriggerText != "" {
+		fmt.Fprintf(ww, "%s\n\n", session.TriggerText)
+	}
+	fmt.Fprintf(ww, "This is the trigger for the %s, a timed drill.  Respond now by sending your check-in message to %s at %s.  Check-ins are scored on how quickly they arrive after this message was sent.",
+		session.Name, session.CallSign, english.Conjoin(session.ToBBSes, "or"))
+	fmt.Fprintf(ww, "\n\nFull instructions are at %s/instructions?session=%d\n", config.Get().ServerURL, session.ID)
+	ww.Close()
+	return sb.String()
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/rothskeller/packet/envelope"
+	"github.com/rothskeller/packet/message"
+	"github.com/rothskeller/wppsvr/retrieve"
+	"github.com/rothskeller/wppsvr/store"
+)
+
+// sendExercises sends the exercise messages of running two-way exercise
+// sessions to the addresses on their rosters that haven't been sent one yet.
+// Copies that couldn't be sent are tried again next time, for as long as the
+// session is running.
+func sendExercises(st *store.Store) {
+	for _, session := range st.GetRunningSessions() {
+		if session.ExerciseMsg == nil || len(session.ExerciseTo) == 0 {
+			continue
+		}
+		sendExercise(st, session)
+	}
+}
+
+// sendExercise sends the exercise message of a two-way exercise session to each
+// address on its roster that hasn't successfully been sent one, through a
+// connection to its first BBS.  Each copy gets its own message number, which
+// the participant's reply must reference; a copy that is retried keeps the
+// number it was first given.  The outcome for each participant is recorded so
+// that replies can be matched to it and it can be shown in the session editor.
+func sendExercise(st *store.Store, session *store.Session) {
+	var (
+		sent    = make(map[string]*store.ExerciseMessage)
+		pending []*store.ExerciseMessage
+		count   int
+	)
+	for _, em := range st.GetExerciseMessages(session.ID) {
+		sent[em.CallSign] = em
+	}
+	for _, to := range session.ExerciseTo {
+		call, _, _ := strings.Cut(to, "@")
+		call = strings.ToUpper(call)
+		if em := sent[call]; em == nil {
+			pending = append(pending, &store.ExerciseMessage{
+				Session: session.ID, CallSign: call, MessageID: st.NextMessageID(session.Prefix), To: to,
+			})
+		} else if em.Error != "" {
+			em.To, em.Error = to, ""
+			pending = append(pending, em)
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+	conn := retrieve.ConnectToBBS(session.ToBBSes[0], session.CallSign)
+	for _, em := range pending {
+		em.Sent = time.Now()
+		if conn == nil {
+			em.Error = fmt.Sprintf("could not connect to %s", session.ToBBSes[0])
+		} else {
+			subject, body := exerciseMessage(session, em.MessageID)
+			if err := conn.Send(subject, body, em.To); err != nil {
+				em.Error = err.Error()
+			} else {
+				count++
+			}
+		}
+		if em.Error != "" {
+			log.Printf("ERROR: sending exercise message for %s to %s: %s", session.Name, em.CallSign, em.Error)
+		}
+		st.SaveExerciseMessage(em)
+	}
+	if conn != nil {
+		conn.Close()
+	}
+	if count != 0 {
+		log.Printf("Sent %d exercise messages for %s ending %s.", count, session.Name, session.End.Format("2006-01-02 15:04"))
+	}
+}
+
+// exerciseMessage returns the subject line and body of a copy of the session's
+// exercise message with the specified message number.
+func exerciseMessage(session *store.Session, msgid string) (subject, body string) {
+	// Decode a fresh copy of the message so that we can change its message
+	// number without affecting the session.
+	env, text, _ := envelope.ParseSaved(session.Exercise)
+	msg := message.Decode(env, text)
+	if mb := msg.Base(); mb.FOriginMsgID != nil {
+		*mb.FOriginMsgID = msgid
+	}
+	return msg.EncodeSubject(), new(envelope.Envelope).RenderBody(msg.EncodeBody())
+}
@@ -4,6 +4,7 @@ go 1.21
 
 require (
 	github.com/davecgh/go-spew v1.1.1
+	github.com/go-pdf/fpdf v0.9.0
 	github.com/go-test/deep v1.0.8
 	github.com/mattn/go-sqlite3 v1.14.12
 	github.com/rothskeller/packet v1.10.4
@@ -14,7 +15,6 @@ require (
 require (
 	github.com/creack/goselect v0.1.2 // indirect
 	github.com/dustin/go-humanize v1.0.1 // indirect
-	github.com/go-pdf/fpdf v0.9.0 // indirect
 	github.com/google/uuid v1.6.0 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/ncruces/go-strftime v0.1.9 // indirect
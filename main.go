@@ -60,11 +60,18 @@ func step(st *store.Store) {
 		}
 		sleep5min()
 	}()
-	maybeReopenLog()  // at midnight on the first of each month
-	config.Read()     // re-read config in case it has changed
-	checkBBSes(st)    // retrieve and respond to check-in messages
-	closeSessions(st) // close sessions that are ending and send reports
-	openSessions(st)  // open sessions that should be running
+	maybeReopenLog()      // at midnight on the first of each month
+	config.Read()         // re-read config in case it has changed
+	checkBBSes(st)        // retrieve and respond to check-in messages
+	closeSessions(st)     // close sessions that are ending and send reports
+	openSessions(st)      // open sessions that should be running
+	sendAnnouncements(st) // announce sessions that have opened
+	sendDrillTriggers(st) // send triggers of timed drills that are due
+	sendExercises(st)     // send exercise messages of two-way exercises
+	sendReminders(st)     // remind regulars who haven't checked in yet
+	sendSummaries(st)     // send monthly and yearly summary reports
+	sendReportCards(st)   // send participant report cards
+	sendOutbox(st)        // send queued messages
 }
 
 // lockFH is the singleton lock file used in ensureSingleton.  It is declared at
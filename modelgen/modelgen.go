@@ -0,0 +1,102 @@
+// Package modelgen generates randomized but realistic model messages for form
+// practice sessions, so that session editors don't have to type them by hand.
+// The contents of the generated forms are drawn from the content pools in the
+// configuration.
+package modelgen
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/rothskeller/packet/message"
+	"github.com/rothskeller/wppsvr/config"
+	"github.com/rothskeller/wppsvr/store"
+)
+
+// Types returns the tags of the form types for which model messages can be
+// generated, i.e., those that have content pools in the configuration.  They
+// are returned in sorted order.
+func Types() (tags []string) {
+	for tag := range config.Get().ModelPools {
+		if tag != "*" && message.RegisteredTypes[tag] != nil {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// Generate returns a new model message of the form type with the specified
+// tag, for the specified session.  The message number, date, and time are
+// filled in as if the message were sent during the session, and the handling
+// order and destination as recommended for the form type.  Then each field of
+// the form that has a content pool (for that form type, or failing that, for
+// all form types) is filled with a value chosen at random from that pool.
+// Generate returns nil if the form type has no content pools.
+func Generate(tag string, session *store.Session) message.Message {
+	var (
+		conf   = config.Get()
+		pools  = conf.ModelPools
+		mtypes = message.RegisteredTypes[tag]
+	)
+	if pools[tag] == nil || len(mtypes) == 0 {
+		return nil
+	}
+	msg := message.Create(tag, mtypes[0].Version)
+	if msg == nil {
+		return nil
+	}
+	fillHeader(msg.Base(), session, conf.MessageTypes[tag])
+	for _, f := range msg.Base().Fields {
+		if f.Value == nil {
+			continue
+		}
+		values := pools[tag][f.Label]
+		if values == nil {
+			values = pools["*"][f.Label]
+		}
+		if len(values) != 0 {
+			*f.Value = values[rand.Intn(len(values))]
+		}
+	}
+	return msg
+}
+
+// fillHeader fills in the standard header fields of a generated message:  a
+// message number with the session's prefix, a date and time during the
+// session, and the handling order and destination recommended for the form
+// type.
+func fillHeader(bm *message.BaseMessage, session *store.Session, mtc *config.MessageTypeConfig) {
+	var sent = session.Start
+	if sent.IsZero() || !session.End.After(sent) {
+		sent = time.Now()
+	} else {
+		sent = sent.Add(time.Duration(rand.Int63n(int64(session.End.Sub(sent)))))
+	}
+	setField(bm.FOriginMsgID, fmt.Sprintf("%s-%03dP", session.Prefix, 100+rand.Intn(900)))
+	setField(bm.FMessageDate, sent.Format("01/02/2006"))
+	setField(bm.FMessageTime, sent.Format("15:04"))
+	setField(bm.FHandling, "ROUTINE")
+	if mtc == nil {
+		return
+	}
+	switch mtc.HandlingOrder {
+	case "IMMEDIATE", "PRIORITY", "ROUTINE":
+		setField(bm.FHandling, mtc.HandlingOrder)
+	}
+	if len(mtc.ToICSPosition) != 0 {
+		setField(bm.FToICSPosition, mtc.ToICSPosition[rand.Intn(len(mtc.ToICSPosition))])
+	}
+	if len(mtc.ToLocation) != 0 {
+		setField(bm.FToLocation, mtc.ToLocation[rand.Intn(len(mtc.ToLocation))])
+	}
+}
+
+// setField sets a field of a message, if the message has that field.
+func setField(field *string, value string) {
+	if field != nil {
+		*field = value
+	}
+}
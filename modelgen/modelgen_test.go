@@ -0,0 +1,63 @@
+package modelgen
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rothskeller/packet/xscmsg"
+	"github.com/rothskeller/wppsvr/config"
+	"github.com/rothskeller/wppsvr/store"
+)
+
+func TestGenerate(t *testing.T) {
+	xscmsg.Register()
+	config.SetConfig(&config.Config{
+		MessageTypes: map[string]*config.MessageTypeConfig{
+			"ICS213": {HandlingOrder: "PRIORITY", ToICSPosition: []string{"Planning Section"}},
+		},
+		ModelPools: map[string]map[string][]string{
+			"ICS213": {"Subject": {"Road closure"}},
+			"*":      {"Subject": {"wrong pool"}, "Reference": {"REF-001P"}},
+		},
+	})
+	session := &store.Session{
+		Prefix: "TUE",
+		Start:  time.Date(2022, 4, 12, 0, 0, 0, 0, time.Local),
+		End:    time.Date(2022, 4, 19, 20, 0, 0, 0, time.Local),
+	}
+	if tags := Types(); len(tags) != 1 || tags[0] != "ICS213" {
+		t.Errorf("Types() = %v, want [ICS213]", tags)
+	}
+	if msg := Generate("EOC213RR", session); msg != nil {
+		t.Error("generated a message for a form type with no content pools")
+	}
+	msg := Generate("ICS213", session)
+	if msg == nil {
+		t.Fatal("no message generated")
+	}
+	bm := msg.Base()
+	for _, c := range []struct {
+		name     string
+		field    *string
+		expected string
+	}{
+		// From the form type's own pool, in preference to the "*" pool:
+		{"subject", bm.FSubject, "Road closure"},
+		// From the "*" pool, since the form type has none for it:
+		{"reference", bm.FReference, "REF-001P"},
+		// From the message type configuration:
+		{"handling", bm.FHandling, "PRIORITY"},
+		{"to position", bm.FToICSPosition, "Planning Section"},
+	} {
+		if c.field == nil || *c.field != c.expected {
+			t.Errorf("%s is not %q", c.name, c.expected)
+		}
+	}
+	if bm.FOriginMsgID == nil || len(*bm.FOriginMsgID) != 8 || (*bm.FOriginMsgID)[:4] != "TUE-" {
+		t.Error("message number not generated with session prefix")
+	}
+	if sent, err := time.ParseInLocation("01/02/2006 15:04", *bm.FMessageDate+" "+*bm.FMessageTime, time.Local); err != nil ||
+		sent.Before(session.Start) || sent.After(session.End) {
+		t.Error("message date and time not within session")
+	}
+}
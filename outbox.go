@@ -0,0 +1,43 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/rothskeller/wppsvr/retrieve"
+	"github.com/rothskeller/wppsvr/store"
+)
+
+// sendOutbox sends the messages queued in the outbox, through a connection to
+// the first BBS of each message's session.  Messages that can't be sent are
+// left in the outbox to be tried again next time, for up to a week.
+func sendOutbox(st *store.Store) {
+	var (
+		bySession = make(map[int][]*store.OutboxMessage)
+		order     []int
+	)
+	for _, om := range st.GetUnsentMessages(time.Now().AddDate(0, 0, -7)) {
+		if bySession[om.Session] == nil {
+			order = append(order, om.Session)
+		}
+		bySession[om.Session] = append(bySession[om.Session], om)
+	}
+	for _, sid := range order {
+		session := st.GetSession(sid)
+		if session == nil || len(session.ToBBSes) == 0 {
+			continue
+		}
+		conn := retrieve.ConnectToBBS(session.ToBBSes[0], session.CallSign)
+		if conn == nil {
+			continue // try again next time
+		}
+		for _, om := range bySession[sid] {
+			if err := conn.Send(om.Subject, om.Body, om.To); err != nil {
+				log.Printf("ERROR: sending queued message from %s to %s: %s", session.CallSign, om.To, err)
+				continue
+			}
+			st.MarkMessageSent(om)
+		}
+		conn.Close()
+	}
+}
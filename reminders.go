@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rothskeller/packet/envelope"
+	"github.com/rothskeller/packet/message"
+	"github.com/rothskeller/wppsvr/config"
+	"github.com/rothskeller/wppsvr/english"
+	"github.com/rothskeller/wppsvr/report"
+	"github.com/rothskeller/wppsvr/store"
+)
+
+// reminderRegulars holds the regular participants of each session in its
+// reminder window.  They are found once per session, since finding them reads
+// the messages of many earlier sessions, and those don't change.
+var reminderRegulars = make(map[int]map[string]string)
+
+// sendReminders queues reminders to the regular participants of running
+// sessions who haven't checked in yet, once the sessions are within the
+// configured reminder time of their ends.
+func sendReminders(st *store.Store) {
+	var (
+		hours   = config.Get().ReminderHours
+		now     = time.Now()
+		current = make(map[int]map[string]string)
+	)
+	if hours == 0 {
+		return
+	}
+	for _, session := range st.GetRunningSessions() {
+		if session.Flags&store.DontSendResponses != 0 {
+			continue
+		}
+		if now.Before(session.End.Add(-time.Duration(hours)*time.Hour)) || !now.Before(session.End) {
+			continue
+		}
+		regulars, ok := reminderRegulars[session.ID]
+		if !ok {
+			regulars = report.Regulars(st, session)
+		}
+		current[session.ID] = regulars
+		sendSessionReminders(st, session, regulars)
+	}
+	reminderRegulars = current
+}
+
+// sendSessionReminders queues reminders to those of the regular participants
+// of the session who haven't checked in yet, except those who have already
+// been reminded and those who have opted out.
+func sendSessionReminders(st *store.Store, session *store.Session, regulars map[string]string) {
+	var (
+		missing map[string]string
+		calls   []string
+	)
+	if len(regulars) == 0 {
+		return
+	}
+	missing = report.MissingRegulars(st, session, regulars)
+	for call := range missing {
+		if !st.ReminderSent(session.ID, call) && !st.GetParticipant(call).NoReminders {
+			calls = append(calls, call)
+		}
+	}
+	sort.Strings(calls)
+	body := new(envelope.Envelope).RenderBody(reminderText(session))
+	for _, call := range calls {
+		st.QueueMessage(&store.OutboxMessage{
+			Session: session.ID,
+			To:      missing[call],
+			Subject: message.EncodeSubject(st.NextMessageID(session.Prefix), "ROUTINE", "", "Packet Practice Reminder"),
+			Body:    body,
+		})
+		st.MarkReminderSent(session.ID, call)
+		log.Printf("Queued reminder for %s to %s.", session.Name, call)
+	}
+}
+
+// reminderText returns the plain text of a reminder to check in to a session.
+func reminderText(session *store.Session) string {
+	var (
+		sb  strings.Builder
+		ww  = english.NewWrapper(&sb)
+		url = config.Get().ServerURL
+	)
+	fmt.Fprintf(ww, "This is a reminder that the %s closes at %s, and we haven't received your check-in yet.  We hope to hear from you!",
+		session.Name, session.End.Format("15:04 on Monday, January 2"))
+	fmt.Fprintf(ww, "\n\nInstructions are at %s/instructions?session=%d\n", url, session.ID)
+	fmt.Fprintf(ww, "\nTo stop these reminders, visit %s/profile\n", url)
+	ww.Close()
+	return sb.String()
+}
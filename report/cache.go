@@ -0,0 +1,71 @@
+package report
+
+import (
+	"sync"
+
+	"github.com/rothskeller/wppsvr/store"
+)
+
+// VersionedStore is an interface covering those methods of store.Store that
+// are used in generating cached reports.
+type VersionedStore interface {
+	Store
+	SessionVersion(int) int
+}
+
+// cacheSize is the maximum number of reports held in the cache.  It is enough
+// for a year's worth of sessions, which is the most that any single page (the
+// problem statistics page) uses.  When the cache is full, the least recently
+// used report is discarded.
+const cacheSize = 250
+
+// cache holds the most recently generated report for each of the most
+// recently used sessions, along with the session version from which it was
+// generated.
+var cache = struct {
+	sync.Mutex
+	reports map[int]*cachedReport
+	clock   uint64
+}{reports: make(map[int]*cachedReport)}
+
+type cachedReport struct {
+	version int
+	report  *Report
+	used    uint64
+}
+
+// Cached returns the report for the specified session.  It returns a report
+// generated earlier if the session and its messages have not changed since
+// then, and generates a new one otherwise.  The returned report is shared and
+// must not be modified.  Note that it may not reflect changes in other sessions
+// (e.g., in the weekly summary) or in response read receipts.
+func Cached(st VersionedStore, session *store.Session) *Report {
+	if session.ID == 0 {
+		return Generate(st, session)
+	}
+	var version = st.SessionVersion(session.ID)
+	cache.Lock()
+	if cr := cache.reports[session.ID]; cr != nil && cr.version == version {
+		cache.clock++
+		cr.used = cache.clock
+		cache.Unlock()
+		return cr.report
+	}
+	cache.Unlock()
+	cr := &cachedReport{version: version, report: Generate(st, session)}
+	cache.Lock()
+	defer cache.Unlock()
+	cache.clock++
+	cr.used = cache.clock
+	cache.reports[session.ID] = cr
+	if len(cache.reports) > cacheSize {
+		var oldest int
+		for id, c := range cache.reports {
+			if oldest == 0 || c.used < cache.reports[oldest].used {
+				oldest = id
+			}
+		}
+		delete(cache.reports, oldest)
+	}
+	return cr.report
+}
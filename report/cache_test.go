@@ -0,0 +1,51 @@
+package report
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rothskeller/wppsvr/store"
+)
+
+// versionedStore is a historyStore with session versions.
+type versionedStore struct {
+	historyStore
+	versions map[int]int
+}
+
+func (v *versionedStore) SessionVersion(id int) int { return v.versions[id] }
+
+func TestCacheEviction(t *testing.T) {
+	var st = versionedStore{versions: make(map[int]int)}
+
+	cache.reports = make(map[int]*cachedReport)
+	for id := 1; id <= cacheSize+1; id++ {
+		st.add(&store.Session{ID: id, CallSign: fmt.Sprintf("PKT%03d", id), End: time.Date(2022, 1, 1, 20, 0, 0, 0, time.Local).AddDate(0, 0, id)})
+	}
+	first := Cached(&st, st.sessions[0])
+	second := Cached(&st, st.sessions[1])
+	for _, session := range st.sessions[2:cacheSize] {
+		Cached(&st, session)
+	}
+	// Use the first session again, so that the second is now the least
+	// recently used.  Adding one more session to the full cache evicts it.
+	if Cached(&st, st.sessions[0]) != first {
+		t.Error("report for first session regenerated")
+	}
+	Cached(&st, st.sessions[cacheSize])
+	if len(cache.reports) != cacheSize {
+		t.Errorf("cache has %d reports, want %d", len(cache.reports), cacheSize)
+	}
+	if Cached(&st, st.sessions[0]) != first {
+		t.Error("report for recently used session evicted")
+	}
+	if Cached(&st, st.sessions[1]) == second {
+		t.Error("report for least recently used session not evicted")
+	}
+	// A change in the session version invalidates its cached report.
+	st.versions[1]++
+	if Cached(&st, st.sessions[0]) == first {
+		t.Error("report not regenerated after version change")
+	}
+}
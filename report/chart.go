@@ -0,0 +1,189 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// The trend charts are rendered as inline SVG, with all styling in attributes,
+// so that they need no JavaScript or style sheet and work in email as well as
+// on the web site.
+const (
+	chartWidth   = 320 // overall width of chart
+	chartHeight  = 160 // overall height of chart
+	chartLeft    = 32  // width of the Y axis label area
+	chartBottom  = 20  // height of the X axis label area
+	chartTop     = 16  // margin above the plot area, holding the legend
+	chartRight   = 8   // margin right of the plot area
+	chartFont    = `font-family="Helvetica,Arial,sans-serif" font-size="10" fill="#666"`
+	chartBBS     = "#4e79a7"
+	chartWinlink = "#f28e2b"
+	chartEmail   = "#59a14f"
+)
+
+// callSignChart returns an SVG line chart of the number of unique call signs
+// in each session of the trend.
+func callSignChart(trends []*TrendPoint) template.HTML {
+	var values = make([]int, len(trends))
+	for i, tp := range trends {
+		values[i] = tp.UniqueCallSigns
+	}
+	return lineChart(trends, values, 0, "")
+}
+
+// scoreChart returns an SVG line chart of the average valid score in each
+// session of the trend.
+func scoreChart(trends []*TrendPoint) template.HTML {
+	var values = make([]int, len(trends))
+	for i, tp := range trends {
+		values[i] = tp.AverageScore
+	}
+	return lineChart(trends, values, 100, "%")
+}
+
+// sourceChart returns an SVG stacked bar chart of the number of counted
+// messages from each kind of source in each session of the trend, with a
+// legend.
+func sourceChart(trends []*TrendPoint) template.HTML {
+	var (
+		sb   strings.Builder
+		top  int
+		slot = plotWidth() / float64(len(trends))
+	)
+	for _, tp := range trends {
+		top = max(top, tp.BBS+tp.Winlink+tp.Email)
+	}
+	top = chartScale(top)
+	chartStart(&sb, trendDates(trends), top, "")
+	for i, tp := range trends {
+		var x = float64(chartLeft) + slot*float64(i) + slot/6
+		var y = float64(chartHeight - chartBottom)
+		for _, seg := range []struct {
+			count int
+			color string
+		}{{tp.BBS, chartBBS}, {tp.Winlink, chartWinlink}, {tp.Email, chartEmail}} {
+			if seg.count == 0 {
+				continue
+			}
+			var h = plotHeight() * float64(seg.count) / float64(top)
+			y -= h
+			fmt.Fprintf(&sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"/>`, x, y, slot*2/3, h, seg.color)
+		}
+	}
+	for i, leg := range []struct {
+		name  string
+		color string
+	}{{"BBS", chartBBS}, {"Winlink", chartWinlink}, {"Email", chartEmail}} {
+		var x = chartLeft + 8 + 64*i
+		fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="8" height="8" fill="%s"/><text x="%d" y="%d" %s>%s</text>`,
+			x, 2, leg.color, x+11, 10, chartFont, leg.name)
+	}
+	sb.WriteString(`</svg>`)
+	return template.HTML(sb.String())
+}
+
+// histogramChart returns an SVG bar chart of the supplied counts, labeled with
+// their names.
+func histogramChart(counts []*Count) template.HTML {
+	var (
+		sb     strings.Builder
+		top    int
+		slot   = plotWidth() / float64(len(counts))
+		labels = make([]string, len(counts))
+	)
+	for i, c := range counts {
+		top = max(top, c.Count)
+		labels[i] = c.Name
+	}
+	top = chartScale(top)
+	chartStart(&sb, labels, top, "")
+	for i, c := range counts {
+		var h = plotHeight() * float64(c.Count) / float64(top)
+		fmt.Fprintf(&sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"/>`,
+			float64(chartLeft)+slot*float64(i)+slot/6, float64(chartHeight-chartBottom)-h, slot*2/3, h, chartBBS)
+	}
+	sb.WriteString(`</svg>`)
+	return template.HTML(sb.String())
+}
+
+// lineChart returns an SVG line chart of the supplied values, which correspond
+// to the supplied trend points.  If top is zero, the Y axis is scaled to fit
+// the values.  The unit, if any, is appended to the Y axis labels.
+func lineChart(trends []*TrendPoint, values []int, top int, unit string) template.HTML {
+	var (
+		sb     strings.Builder
+		slot   = plotWidth() / float64(len(trends))
+		xs     = make([]float64, len(values))
+		ys     = make([]float64, len(values))
+		points = make([]string, len(values))
+	)
+	if top == 0 {
+		for _, v := range values {
+			top = max(top, v)
+		}
+		top = chartScale(top)
+	}
+	chartStart(&sb, trendDates(trends), top, unit)
+	for i, v := range values {
+		xs[i] = float64(chartLeft) + slot*(float64(i)+0.5)
+		ys[i] = float64(chartHeight-chartBottom) - plotHeight()*float64(v)/float64(top)
+		points[i] = fmt.Sprintf("%.1f,%.1f", xs[i], ys[i])
+	}
+	fmt.Fprintf(&sb, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`, strings.Join(points, " "), chartBBS)
+	for i := range points {
+		fmt.Fprintf(&sb, `<circle cx="%.1f" cy="%.1f" r="3" fill="%s"/>`, xs[i], ys[i], chartBBS)
+	}
+	sb.WriteString(`</svg>`)
+	return template.HTML(sb.String())
+}
+
+// chartStart writes the opening of an SVG chart:  the svg element itself, the
+// Y axis with labels at zero and top, and the X axis with the supplied label
+// under each data point.  Labels are thinned out when there are too many to
+// fit.
+func chartStart(sb *strings.Builder, labels []string, top int, unit string) {
+	var (
+		bottom = chartHeight - chartBottom
+		slot   = plotWidth() / float64(len(labels))
+		every  = (len(labels) + 5) / 6
+	)
+	fmt.Fprintf(sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`,
+		chartWidth, chartHeight, chartWidth, chartHeight)
+	fmt.Fprintf(sb, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#ccc"/>`, chartLeft, chartTop, chartWidth-chartRight, chartTop)
+	fmt.Fprintf(sb, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#999"/>`, chartLeft, bottom, chartWidth-chartRight, bottom)
+	fmt.Fprintf(sb, `<text x="%d" y="%d" text-anchor="end" %s>%d%s</text>`, chartLeft-4, chartTop+4, chartFont, top, unit)
+	fmt.Fprintf(sb, `<text x="%d" y="%d" text-anchor="end" %s>0%s</text>`, chartLeft-4, bottom+4, chartFont, unit)
+	for i, label := range labels {
+		// Always label the last point, and every nth point before it.
+		if (len(labels)-1-i)%every != 0 {
+			continue
+		}
+		fmt.Fprintf(sb, `<text x="%.1f" y="%d" text-anchor="middle" %s>%s</text>`,
+			float64(chartLeft)+slot*(float64(i)+0.5), chartHeight-6, chartFont, template.HTMLEscapeString(label))
+	}
+}
+
+// trendDates returns the dates of the supplied trend points, for use as chart
+// labels.
+func trendDates(trends []*TrendPoint) (dates []string) {
+	dates = make([]string, len(trends))
+	for i, tp := range trends {
+		dates[i] = tp.Date
+	}
+	return dates
+}
+
+// chartScale returns a round number at or above the supplied one, to use as the
+// top of a chart's Y axis.
+func chartScale(top int) int {
+	for _, scale := range []int{5, 10, 20, 25, 50, 100, 200, 250, 500} {
+		if top <= scale {
+			return scale
+		}
+	}
+	return (top + 999) / 1000 * 1000
+}
+
+func plotWidth() float64  { return chartWidth - chartLeft - chartRight }
+func plotHeight() float64 { return chartHeight - chartTop - chartBottom }
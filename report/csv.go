@@ -0,0 +1,66 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// RenderCSV renders a report in CSV format, suitable for loading into a
+// spreadsheet.  The messages, sources, jurisdictions, and message type counts
+// are rendered as separate tables, each with its own heading row, separated by
+// blank lines.
+func (r *Report) RenderCSV() string {
+	var sb strings.Builder
+	var w = csv.NewWriter(&sb)
+
+	w.Write([]string{"Message ID", "Call Sign", "From", "Source", "Multiple", "Jurisdiction", "Score", "Summary", "Problems"})
+	for _, m := range r.Messages {
+		var multiple string
+		if m.Multiple {
+			multiple = "yes"
+		}
+		w.Write([]string{m.ID, m.FromCallSign, m.Prefix + m.Suffix, m.Source, multiple, m.Jurisdiction,
+			strconv.Itoa(m.Score), m.Summary, strings.Join(m.Problems, "; ")})
+	}
+	w.Write(nil)
+	w.Write([]string{"Source", "Count", "Simulated Down"})
+	for _, s := range r.Sources {
+		var down string
+		if s.SimulatedDown {
+			down = "yes"
+		}
+		w.Write([]string{s.Name, strconv.Itoa(s.Count), down})
+	}
+	w.Write(nil)
+	w.Write([]string{"Jurisdiction", "Count"})
+	for _, c := range r.Jurisdictions {
+		w.Write([]string{c.Name, strconv.Itoa(c.Count)})
+	}
+	w.Write(nil)
+	w.Write([]string{"Message Type", "Count"})
+	for _, c := range r.MTypeCounts {
+		w.Write([]string{c.Name, strconv.Itoa(c.Count)})
+	}
+	w.Flush()
+	return sb.String()
+}
+
+// RenderJSON renders a report in JSON format.
+func (r *Report) RenderJSON() string {
+	by, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		panic(err) // a Report always marshals
+	}
+	return string(by) + "\n"
+}
+
+// Filename returns the base name (without extension) to use for files
+// containing the report, such as CSV and JSON downloads and attachments.
+func (r *Report) Filename() string {
+	if r.Jurisdiction != "" {
+		return "wpp-report-" + r.sessionEnd.Format("2006-01-02") + "-" + r.Jurisdiction
+	}
+	return "wpp-report-" + r.sessionEnd.Format("2006-01-02")
+}
@@ -0,0 +1,63 @@
+package report
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/rothskeller/wppsvr/analyze"
+	"github.com/rothskeller/wppsvr/config"
+	"github.com/rothskeller/wppsvr/store"
+)
+
+// fastestResponders is the number of responders listed in the report of a
+// timed drill session.
+const fastestResponders = 10
+
+// generateDrill generates the response time statistics for a timed drill
+// session:  a histogram of the response times of the counted messages, using
+// the configured response time brackets, and a list of the fastest responders.
+func generateDrill(r *Report, session *store.Session, messages []*store.Message) {
+	type response struct {
+		call    string
+		latency time.Duration
+	}
+	if session.TriggerSent.IsZero() {
+		// Either this isn't a timed drill, or its trigger message
+		// hasn't gone out, in which case nothing has responded to it.
+		return
+	}
+	var (
+		brackets  = config.Get().Drill.Brackets
+		counts    = make([]int, len(brackets)+1)
+		responses []response
+		trigger   = session.TriggerSent
+	)
+	r.Drill = &Drill{TriggerTime: trigger.Format("Mon 2006-01-02 15:04")}
+	messages, _, _ = removeInvalidAndReplaced(messages)
+	for _, m := range messages {
+		var (
+			latency = analyze.ReceivedDate(m).Sub(trigger)
+			idx     = len(brackets)
+		)
+		if minutes, _ := analyze.DrillBracket(latency); minutes != 0 {
+			idx = slices.IndexFunc(brackets, func(b *config.DrillBracket) bool { return b.Minutes == minutes })
+		}
+		counts[idx]++
+		if m.FromCallSign != "" {
+			responses = append(responses, response{m.FromCallSign, latency})
+		}
+	}
+	for i, b := range brackets {
+		r.Drill.Latencies = append(r.Drill.Latencies, &Count{Name: fmt.Sprintf("≤%dm", b.Minutes), Count: counts[i]})
+	}
+	r.Drill.Latencies = append(r.Drill.Latencies, &Count{Name: fmt.Sprintf(">%dm", brackets[len(brackets)-1].Minutes), Count: counts[len(brackets)]})
+	sort.SliceStable(responses, func(i, j int) bool { return responses[i].latency < responses[j].latency })
+	for i := 0; i < len(responses) && i < fastestResponders; i++ {
+		r.Drill.Fastest = append(r.Drill.Fastest, &Responder{
+			CallSign: responses[i].call,
+			Latency:  analyze.FormatLatency(responses[i].latency),
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package report
+
+import (
+	"time"
+
+	"github.com/rothskeller/wppsvr/analyze"
+	"github.com/rothskeller/wppsvr/store"
+)
+
+// generateExercise generates the reply statistics for a two-way exercise
+// session.  A participant has replied if they sent a counted message; their
+// turnaround time is measured from the sending of their exercise message to
+// the arrival of their reply.
+func generateExercise(r *Report, st Store, session *store.Session, messages []*store.Message) {
+	var (
+		replies = make(map[string]*store.Message)
+		total   time.Duration
+	)
+	if session.Exercise == "" {
+		return
+	}
+	r.Exercise = new(Exercise)
+	messages, _, _ = removeInvalidAndReplaced(messages)
+	for _, m := range messages {
+		replies[m.FromCallSign] = m
+	}
+	for _, em := range st.GetExerciseMessages(session.ID) {
+		if em.Error != "" {
+			continue
+		}
+		r.Exercise.Sent++
+		if m := replies[em.CallSign]; m != nil {
+			r.Exercise.Replied++
+			total += analyze.ReceivedDate(m).Sub(em.Sent)
+		}
+	}
+	if r.Exercise.Replied != 0 {
+		r.Exercise.AverageTurnaround = analyze.FormatLatency(total / time.Duration(r.Exercise.Replied))
+	}
+}
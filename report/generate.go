@@ -9,37 +9,58 @@ import (
 	"time"
 
 	"github.com/rothskeller/packet/message"
+	"github.com/rothskeller/wppsvr/analyze"
 	"github.com/rothskeller/wppsvr/english"
 	"github.com/rothskeller/wppsvr/store"
 )
 
 // Generate generates the report for the specified session.
 func Generate(st Store, session *store.Session) *Report {
+	return generate(st, session, "")
+}
+
+// generate generates the report for the specified session.  If jurisdiction
+// is not empty, the report covers only messages from that jurisdiction.
+func generate(st Store, session *store.Session, jurisdiction string) *Report {
 	var (
 		r        Report
 		messages []*store.Message
 		count    int
 	)
 	messages = st.GetSessionMessages(session.ID)
+	if jurisdiction != "" {
+		messages = filterJurisdiction(messages, jurisdiction)
+	}
 	count = len(messages)
 	messages = removeDroppedMessages(messages)
 	r.DroppedCount = count - len(messages)
 	generateTitle(&r, session)
 	generateParams(&r, session)
 	generateStatistics(&r, session, messages)
-	generateWeekSummary(&r, st, session)
+	generateExercise(&r, st, session, messages)
+	generateDrill(&r, session, messages)
+	if jurisdiction == "" {
+		generateWeekSummary(&r, st, session)
+		generateTrends(&r, st, session)
+	} else {
+		generateComparison(&r, st, session, jurisdiction)
+	}
+	generateFeedback(&r, st, session, messages)
 	generateMessages(&r, messages)
+	generateProblems(&r, messages)
 	generateGenInfo(&r, session)
 	generateParticipants(&r, messages)
 	return &r
 }
 
 // removeDroppedMessages removes from the messages list any messages that
-// should be excluded from the report (e.g., delivery receipts).
+// should be excluded from the report (e.g., delivery receipts, and read
+// receipts for our responses, which are the only READ messages without
+// problems).
 func removeDroppedMessages(messages []*store.Message) []*store.Message {
 	j := 0
 	for _, m := range messages {
-		if m.MessageType != "DELIVERED" {
+		if m.MessageType != "DELIVERED" && (m.MessageType != "READ" || m.Summary != "") {
 			messages[j] = m
 			j++
 		}
@@ -51,6 +72,7 @@ func removeDroppedMessages(messages []*store.Message) []*store.Message {
 func generateTitle(r *Report, session *store.Session) {
 	r.SessionName = session.Name
 	r.SessionDate = session.End.Format("Monday, January 2, 2006")
+	r.sessionEnd = session.End
 	if session.Flags&store.Running != 0 {
 		r.Preliminary = true
 	}
@@ -118,14 +140,36 @@ func generateWeekSummary(r *Report, st Store, session *store.Session) {
 	sessions = sessions[:len(sessions)-1]
 	// Now add the unique call signs from the other sessions in the week.
 	for _, osession := range sessions {
-		oreport := Generate(st, osession)
-		for cs := range oreport.uniqueCallSigns {
-			unique[cs] = struct{}{}
+		messages, _, _ := removeInvalidAndReplaced(removeDroppedMessages(st.GetSessionMessages(osession.ID)))
+		for _, m := range messages {
+			if m.FromCallSign != "" {
+				unique[m.FromCallSign] = struct{}{}
+			}
 		}
 	}
 	r.UniqueCallSignsWeek = len(unique)
 }
 
+// generateFeedback counts the responses we sent to the messages in the report,
+// and how many of them we know were read because we got read receipts for
+// them.
+func generateFeedback(r *Report, st Store, session *store.Session, messages []*store.Message) {
+	var reported = make(map[string]bool)
+
+	for _, m := range messages {
+		reported[m.LocalID] = true
+	}
+	for _, resp := range st.GetSessionResponses(session.ID) {
+		if !reported[resp.ResponseTo] {
+			continue
+		}
+		r.FeedbackSent++
+		if !resp.ReadTime.IsZero() {
+			r.FeedbackRead++
+		}
+	}
+}
+
 // generateStatistics scans the messages accumulated in the session and computes
 // the statistics that we will display.
 func generateStatistics(r *Report, session *store.Session, messages []*store.Message) {
@@ -136,13 +180,7 @@ func generateStatistics(r *Report, session *store.Session, messages []*store.Mes
 	r.uniqueCallSigns = make(map[string]struct{})
 	messages, r.InvalidCount, r.ReplacedCount = removeInvalidAndReplaced(messages)
 	for _, m := range messages {
-		if m.FromBBS != "" {
-			sources[m.FromBBS]++
-		} else if strings.HasSuffix(strings.ToLower(m.FromAddress), "@winlink.org") {
-			sources["Winlink"]++
-		} else {
-			sources["Email"]++
-		}
+		sources[messageSource(m)]++
 		if len(m.Jurisdiction) == 3 {
 			jurisdictions[m.Jurisdiction]++
 		} else {
@@ -192,6 +230,18 @@ func generateStatistics(r *Report, session *store.Session, messages []*store.Mes
 	sort.Slice(r.MTypeCounts, func(i, j int) bool { return r.MTypeCounts[i].Name < r.MTypeCounts[j].Name })
 }
 
+// messageSource returns the source of a message, for the report:  the BBS it
+// was sent from, "Winlink", or "Email".
+func messageSource(m *store.Message) string {
+	if m.FromBBS != "" {
+		return m.FromBBS
+	}
+	if strings.HasSuffix(strings.ToLower(m.FromAddress), "@winlink.org") {
+		return "Winlink"
+	}
+	return "Email"
+}
+
 // removeInvalidAndReplaced removes invalid and replaced messages from the list
 // of messages.
 func removeInvalidAndReplaced(messages []*store.Message) (out []*store.Message, invalid, replaced int) {
@@ -255,13 +305,7 @@ func generateMessages(r *Report, messages []*store.Message) {
 		} else {
 			rm.Prefix, rm.Suffix = "???", "???"
 		}
-		if m.FromBBS != "" {
-			rm.Source = m.FromBBS
-		} else if strings.HasSuffix(strings.ToLower(m.FromAddress), "@winlink.org") {
-			rm.Source = "Winlink"
-		} else {
-			rm.Source = "Email"
-		}
+		rm.Source = messageSource(m)
 		if len(m.Jurisdiction) == 3 {
 			rm.Jurisdiction = m.Jurisdiction
 		} else if m.Jurisdiction != "" {
@@ -269,11 +313,40 @@ func generateMessages(r *Report, messages []*store.Message) {
 		}
 		rm.Score = m.Score
 		rm.Summary = m.Summary
+		rm.Problems = m.Problems
 		rm.Multiple = multiple[m.LocalID]
 		r.Messages = append(r.Messages, &rm)
 	}
 	sort.Slice(r.Messages, func(i, j int) bool { return compareMessages(r.Messages[i], r.Messages[j]) })
 }
+
+// generateProblems counts the number of messages exhibiting each problem.
+// Only the last message from each address is considered, but messages that
+// didn't count as check-ins are included.
+func generateProblems(r *Report, messages []*store.Message) {
+	var problems = make(map[string]int)
+
+	messages, _ = removeReplaced(messages)
+	for _, m := range messages {
+		for _, code := range m.Problems {
+			problems[code]++
+		}
+	}
+	r.Problems = make([]*Count, 0, len(problems))
+	for code, count := range problems {
+		r.Problems = append(r.Problems, &Count{
+			Name:  analyze.ProblemLabel(code),
+			Count: count,
+		})
+	}
+	sort.Slice(r.Problems, func(i, j int) bool {
+		if r.Problems[i].Count != r.Problems[j].Count {
+			return r.Problems[i].Count > r.Problems[j].Count
+		}
+		return r.Problems[i].Name < r.Problems[j].Name
+	})
+}
+
 func compareMessages(a, b *Message) bool {
 	if a.FromCallSign != "" && b.FromCallSign == "" {
 		return true
@@ -0,0 +1,67 @@
+package report
+
+import (
+	"sort"
+
+	"github.com/rothskeller/wppsvr/store"
+)
+
+// GenerateJurisdiction generates a report for the specified session that
+// covers only messages from the specified jurisdiction.  It includes a
+// comparison with the previous session of the same net.
+func GenerateJurisdiction(st Store, session *store.Session, jurisdiction string) *Report {
+	var r = generate(st, session, jurisdiction)
+
+	r.Jurisdiction = jurisdiction
+	return r
+}
+
+// filterJurisdiction returns the subset of the messages that come from the
+// specified jurisdiction.
+func filterJurisdiction(messages []*store.Message, jurisdiction string) (out []*store.Message) {
+	for _, m := range messages {
+		if m.Jurisdiction == jurisdiction {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// generateComparison compares the call signs in a jurisdiction-scoped report
+// with those from the same jurisdiction in the previous session of the same
+// net (i.e., with the same call sign).  If there is no such session in the
+// preceding five weeks, no comparison is made.
+func generateComparison(r *Report, st Store, session *store.Session, jurisdiction string) {
+	var (
+		previous *store.Session
+		calls    = make(map[string]bool)
+	)
+	for _, s := range st.GetSessions(session.End.AddDate(0, 0, -35), session.End) {
+		if s.CallSign == session.CallSign && s.ID != session.ID {
+			previous = s
+		}
+	}
+	if previous == nil {
+		return
+	}
+	r.Comparison = &Comparison{PreviousSessionDate: previous.End.Format("Monday, January 2, 2006")}
+	messages, _, _ := removeInvalidAndReplaced(removeDroppedMessages(filterJurisdiction(st.GetSessionMessages(previous.ID), jurisdiction)))
+	for _, m := range messages {
+		if m.FromCallSign != "" {
+			calls[m.FromCallSign] = true
+		}
+	}
+	r.Comparison.PreviousUniqueCallSigns = len(calls)
+	for call := range r.uniqueCallSigns {
+		if !calls[call] {
+			r.Comparison.NewCallSigns = append(r.Comparison.NewCallSigns, call)
+		}
+	}
+	for call := range calls {
+		if _, ok := r.uniqueCallSigns[call]; !ok {
+			r.Comparison.MissingCallSigns = append(r.Comparison.MissingCallSigns, call)
+		}
+	}
+	sort.Strings(r.Comparison.NewCallSigns)
+	sort.Strings(r.Comparison.MissingCallSigns)
+}
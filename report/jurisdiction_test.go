@@ -0,0 +1,69 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+
+	"github.com/rothskeller/wppsvr/store"
+)
+
+func TestFilterJurisdiction(t *testing.T) {
+	messages := []*store.Message{
+		{LocalID: "TST-001P", Jurisdiction: "SNY"},
+		{LocalID: "TST-002P", Jurisdiction: "MTV"},
+		{LocalID: "TST-003P", Jurisdiction: ""},
+		{LocalID: "TST-004P", Jurisdiction: "SNY"},
+	}
+	out := filterJurisdiction(messages, "SNY")
+	if len(out) != 2 || out[0].LocalID != "TST-001P" || out[1].LocalID != "TST-004P" {
+		t.Errorf("filterJurisdiction returned %d messages", len(out))
+	}
+	if out = filterJurisdiction(messages, "XSC"); len(out) != 0 {
+		t.Errorf("filterJurisdiction returned %d messages for absent jurisdiction", len(out))
+	}
+}
+
+func TestGenerateJurisdiction(t *testing.T) {
+	var st historyStore
+
+	// The previous session of the same net.
+	st.add(&store.Session{ID: 1, CallSign: "PKTTUE", Name: "SVECS Net", End: time.Date(2022, 4, 12, 20, 0, 0, 0, time.Local)},
+		&store.Message{FromCallSign: "KC6RSC", Jurisdiction: "SNY", Score: 100},
+		&store.Message{FromCallSign: "AA6BT", Jurisdiction: "SNY", Score: 100},
+		&store.Message{FromCallSign: "K6MTV", Jurisdiction: "MTV", Score: 100},
+	)
+	// A more recent session of a different net, which is not compared.
+	st.add(&store.Session{ID: 2, CallSign: "PKTMON", Name: "SPECS Net", End: time.Date(2022, 4, 18, 20, 0, 0, 0, time.Local)},
+		&store.Message{FromCallSign: "W6XYZ", Jurisdiction: "SNY", Score: 100},
+	)
+	session := &store.Session{ID: 3, CallSign: "PKTTUE", Name: "SVECS Net", End: time.Date(2022, 4, 19, 20, 0, 0, 0, time.Local)}
+	st.add(session,
+		&store.Message{LocalID: "TST-001P", FromCallSign: "KC6RSC", Jurisdiction: "SNY", Score: 100},
+		&store.Message{LocalID: "TST-002P", FromCallSign: "K6SNY", Jurisdiction: "SNY", Score: 80},
+		&store.Message{LocalID: "TST-003P", FromCallSign: "K6MTV", Jurisdiction: "MTV", Score: 100},
+	)
+	r := GenerateJurisdiction(&st, session, "SNY")
+	if r.Jurisdiction != "SNY" || r.ValidCount != 2 || r.AverageValidScore != 90 || len(r.Messages) != 2 {
+		t.Errorf("report has jurisdiction %q, %d valid messages, average %d, %d listed",
+			r.Jurisdiction, r.ValidCount, r.AverageValidScore, len(r.Messages))
+	}
+	for _, m := range r.Messages {
+		if m.Jurisdiction != "SNY" {
+			t.Errorf("message %s from %s listed", m.ID, m.Jurisdiction)
+		}
+	}
+	if r.Trends != nil || r.Missed != nil {
+		t.Error("jurisdiction report includes net-wide trends or missed list")
+	}
+	expected := &Comparison{
+		PreviousSessionDate:     "Tuesday, April 12, 2022",
+		PreviousUniqueCallSigns: 2,
+		NewCallSigns:            []string{"K6SNY"},
+		MissingCallSigns:        []string{"AA6BT"},
+	}
+	for _, diff := range deep.Equal(r.Comparison, expected) {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,127 @@
+package report
+
+import (
+	"bytes"
+	"log"
+	"maps"
+	"sort"
+	"text/template"
+
+	"github.com/rothskeller/packet/envelope"
+	"github.com/rothskeller/packet/message"
+	"github.com/rothskeller/wppsvr/config"
+	"github.com/rothskeller/wppsvr/store"
+)
+
+// AddMissed adds to the report the list of regular participants of the net who
+// did not check in to this session.  It also remembers the address from which
+// each of them last checked in, for sending them a missed-you message.  This
+// isn't done by Generate because finding the regulars reads the messages of
+// many earlier sessions; it is done only where the list is used, when sending
+// the report and when showing it to those who can see everyone's check-ins.
+func (r *Report) AddMissed(st Store, session *store.Session) {
+	r.missedAddresses = Regulars(st, session)
+	for call := range r.missedAddresses {
+		if _, ok := r.uniqueCallSigns[call]; ok {
+			delete(r.missedAddresses, call)
+		} else {
+			r.Missed = append(r.Missed, call)
+		}
+	}
+	sort.Strings(r.Missed)
+}
+
+// MissingRegulars returns those of the regular participants of the session's
+// net, as returned by Regulars, who have not (yet) checked in to the session.
+// The returned map gives the address from which each of them last checked in
+// to the net.
+func MissingRegulars(st Store, session *store.Session, regulars map[string]string) (missing map[string]string) {
+	missing = maps.Clone(regulars)
+	messages, _, _ := removeInvalidAndReplaced(removeDroppedMessages(st.GetSessionMessages(session.ID)))
+	for _, m := range messages {
+		delete(missing, m.FromCallSign)
+	}
+	return missing
+}
+
+// Regulars returns the regular participants of the session's net (i.e.,
+// sessions with the same call sign):  those who checked in to enough of the
+// net's sessions before this one, as configured.  The returned map gives the
+// address from which each of them last checked in.
+func Regulars(st Store, session *store.Session) (regulars map[string]string) {
+	var (
+		conf      config.MissedYouConfig
+		sessions  []*store.Session
+		counts    = make(map[string]int)
+		addresses = make(map[string]string)
+	)
+	for _, s := range st.GetSessions(session.End.AddDate(-1, 0, 0), session.End) {
+		if s.CallSign == session.CallSign && s.ID != 0 && s.ID != session.ID && s.Flags&store.Imported == 0 {
+			sessions = append(sessions, s)
+		}
+	}
+	if len(sessions) == 0 {
+		return nil // no history
+	}
+	if conf = config.Get().MissedYou; len(sessions) < conf.Regular {
+		return nil
+	}
+	if len(sessions) > conf.Sessions {
+		sessions = sessions[len(sessions)-conf.Sessions:]
+	}
+	for _, s := range sessions {
+		var seen = make(map[string]bool)
+		messages, _, _ := removeInvalidAndReplaced(removeDroppedMessages(st.GetSessionMessages(s.ID)))
+		for _, m := range messages {
+			if m.FromCallSign == "" {
+				continue
+			}
+			addresses[m.FromCallSign] = m.FromAddress
+			if !seen[m.FromCallSign] {
+				seen[m.FromCallSign] = true
+				counts[m.FromCallSign]++
+			}
+		}
+	}
+	regulars = make(map[string]string)
+	for call, count := range counts {
+		if count >= conf.Regular {
+			regulars[call] = addresses[call]
+		}
+	}
+	return regulars
+}
+
+// sendMissedYou queues a friendly message to each of the regular participants
+// who missed the session, in the outbox of the session's mailbox.
+func sendMissedYou(st Store, session *store.Session, r *Report) {
+	var (
+		conf = config.Get()
+		tmpl *template.Template
+		err  error
+	)
+	if tmpl, err = template.New("missedYou").Parse(conf.MissedYou.Message); err != nil {
+		log.Printf("ERROR: missed-you message template: %s", err)
+		return
+	}
+	for _, call := range r.Missed {
+		var body bytes.Buffer
+		var vars = struct {
+			CallSign    string
+			SessionName string
+			SessionDate string
+			ServerURL   string
+		}{call, session.Name, session.End.Format("January 2"), conf.ServerURL}
+
+		if err = tmpl.Execute(&body, &vars); err != nil {
+			log.Printf("ERROR: missed-you message for %s: %s", call, err)
+			continue
+		}
+		st.QueueMessage(&store.OutboxMessage{
+			Session: session.ID,
+			To:      r.missedAddresses[call],
+			Subject: message.EncodeSubject(st.NextMessageID(session.Prefix), "ROUTINE", "", "We Missed You"),
+			Body:    new(envelope.Envelope).RenderBody(body.String()),
+		})
+	}
+}
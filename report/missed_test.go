@@ -0,0 +1,98 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+
+	"github.com/rothskeller/wppsvr/config"
+	"github.com/rothskeller/wppsvr/store"
+)
+
+// missedHistory returns a historyStore with several sessions of the SVECS Net,
+// and the last of them, which is the one being reported on.
+func missedHistory() (*historyStore, *store.Session) {
+	var st historyStore
+
+	// Imported sessions and sessions of other nets don't count.
+	st.add(&store.Session{ID: 1, CallSign: "PKTTUE", End: time.Date(2022, 3, 15, 20, 0, 0, 0, time.Local), Flags: store.Imported})
+	st.add(&store.Session{ID: 2, CallSign: "PKTTUE", End: time.Date(2022, 3, 22, 20, 0, 0, 0, time.Local)},
+		&store.Message{FromCallSign: "AA6BT", Score: 100},
+		&store.Message{FromCallSign: "W6OLD", Score: 100},
+	)
+	st.add(&store.Session{ID: 3, CallSign: "PKTTUE", End: time.Date(2022, 3, 29, 20, 0, 0, 0, time.Local)},
+		&store.Message{FromCallSign: "KC6RSC", Score: 100},
+		&store.Message{FromCallSign: "W6OLD", Score: 100},
+	)
+	st.add(&store.Session{ID: 4, CallSign: "PKTTUE", End: time.Date(2022, 4, 5, 20, 0, 0, 0, time.Local)},
+		&store.Message{FromCallSign: "KC6RSC", FromAddress: "kc6rsc@w2xsc.ampr.org", Score: 100},
+		&store.Message{FromCallSign: "AA6BT", Score: 100},
+		&store.Message{FromCallSign: "K6SNY", Score: 0},
+	)
+	st.add(&store.Session{ID: 5, CallSign: "PKTMON", End: time.Date(2022, 4, 11, 20, 0, 0, 0, time.Local)},
+		&store.Message{FromCallSign: "W6MON", Score: 100},
+	)
+	// Only the last message from each address counts, but the address of
+	// a regular is the one from which they last checked in.
+	st.add(&store.Session{ID: 6, CallSign: "PKTTUE", End: time.Date(2022, 4, 12, 20, 0, 0, 0, time.Local)},
+		&store.Message{FromCallSign: "AA6BT", Score: 100},
+		&store.Message{FromCallSign: "AA6BT", FromAddress: "aa6bt@winlink.org", Score: 100},
+		&store.Message{FromCallSign: "K6SNY", Score: 0},
+	)
+	session := &store.Session{ID: 7, CallSign: "PKTTUE", Name: "SVECS Net", Prefix: "TUE", End: time.Date(2022, 4, 19, 20, 0, 0, 0, time.Local)}
+	st.add(session, &store.Message{FromCallSign: "KC6RSC", Score: 100})
+	return &st, session
+}
+
+func TestRegulars(t *testing.T) {
+	st, session := missedHistory()
+	// With the last three sessions considered, AA6BT and KC6RSC each
+	// checked in to two of them.  W6OLD checked in to only one; the other
+	// is too old.  K6SNY's messages weren't counted.
+	config.SetConfig(&config.Config{MissedYou: config.MissedYouConfig{Sessions: 3, Regular: 2}})
+	expected := map[string]string{"AA6BT": "aa6bt@winlink.org", "KC6RSC": "kc6rsc@w2xsc.ampr.org"}
+	for _, diff := range deep.Equal(Regulars(st, session), expected) {
+		t.Error(diff)
+	}
+	config.SetConfig(&config.Config{MissedYou: config.MissedYouConfig{Sessions: 3, Regular: 1}})
+	expected["W6OLD"] = "w6old@w1xsc.ampr.org"
+	for _, diff := range deep.Equal(Regulars(st, session), expected) {
+		t.Error(diff)
+	}
+	config.SetConfig(&config.Config{MissedYou: config.MissedYouConfig{Sessions: 3, Regular: 3}})
+	if regulars := Regulars(st, session); len(regulars) != 0 {
+		t.Errorf("Regulars with threshold 3 = %v", regulars)
+	}
+	// With fewer past sessions than the threshold, there are no regulars.
+	config.SetConfig(&config.Config{MissedYou: config.MissedYouConfig{Sessions: 8, Regular: 5}})
+	if regulars := Regulars(st, session); regulars != nil {
+		t.Errorf("Regulars with too little history = %v", regulars)
+	}
+}
+
+func TestMissedYou(t *testing.T) {
+	var r Report
+
+	st, session := missedHistory()
+	config.SetConfig(&config.Config{
+		ServerURL: "https://example.com",
+		MissedYou: config.MissedYouConfig{Sessions: 3, Regular: 2, Message: config.DefaultMissedYouMessage},
+	})
+	generateStatistics(&r, session, st.GetSessionMessages(session.ID))
+	r.AddMissed(st, session)
+	if len(r.Missed) != 1 || r.Missed[0] != "AA6BT" {
+		t.Errorf("Missed = %v, want [AA6BT]", r.Missed)
+	}
+	sendMissedYou(st, session, &r)
+	if len(st.queued) != 1 {
+		t.Fatalf("%d missed-you messages queued, want 1", len(st.queued))
+	}
+	om := st.queued[0]
+	if om.Session != 7 || om.To != "aa6bt@winlink.org" || !strings.Contains(om.Subject, "We Missed You") ||
+		!strings.Contains(om.Body, "We missed you at the SVECS Net on April 19!") ||
+		!strings.Contains(om.Body, "https://example.com") {
+		t.Errorf("incorrect missed-you message: %+v", om)
+	}
+}
@@ -0,0 +1,274 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/go-pdf/fpdf"
+
+	"github.com/rothskeller/wppsvr/english"
+)
+
+// Page layout of PDF reports, in points.
+const (
+	pdfMargin     = 54
+	pdfLineHeight = 14
+)
+
+// pdfColumn describes one column of a table in a PDF report.
+type pdfColumn struct {
+	heading string
+	width   float64
+	align   string
+}
+
+// RenderPDF renders a report in PDF format, suitable for printing.
+func (r *Report) RenderPDF() ([]byte, error) {
+	var (
+		buf bytes.Buffer
+		pdf = fpdf.New("P", "pt", "Letter", "")
+		tr  = pdf.UnicodeTranslatorFromDescriptor("")
+	)
+	pdf.SetTitle("SCCo Packet Practice Report", true)
+	pdf.SetCreator("wppsvr", true)
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	pdf.AliasNbPages("")
+	pdf.SetHeaderFunc(func() {
+		if pdf.PageNo() == 1 {
+			return
+		}
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.SetTextColor(0x88, 0x88, 0x88)
+		pdf.CellFormat(0, pdfLineHeight, tr(fmt.Sprintf("Packet Practice Report — %s — %s", r.SessionName, r.SessionDate)), "B", 1, "L", false, 0, "")
+		pdf.Ln(pdfLineHeight)
+	})
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargin + pdfLineHeight)
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.SetTextColor(0x88, 0x88, 0x88)
+		pdf.CellFormat(0, pdfLineHeight, fmt.Sprintf("Page %d of {nb}", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+	r.pdfTitle(pdf, tr)
+	r.pdfExpectsResults(pdf, tr)
+	r.pdfMessages(pdf, tr)
+	r.pdfStatistics(pdf, tr)
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.SetTextColor(0x88, 0x88, 0x88)
+	pdf.MultiCell(0, pdfLineHeight, tr(r.GenerationInfo), "", "L", false)
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (r *Report) pdfTitle(pdf *fpdf.Fpdf, tr func(string) string) {
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.SetTextColor(0x44, 0x44, 0x44)
+	pdf.CellFormat(0, 18, tr("Santa Clara County ARES®/RACES"), "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "B", 20)
+	pdf.SetTextColor(0xDD, 0, 0)
+	pdf.CellFormat(0, 24, "Weekly Packet Practice", "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.CellFormat(0, 24, tr(fmt.Sprintf("%s — %s", r.SessionName, r.SessionDate)), "", 1, "C", false, 0, "")
+	if r.Preliminary {
+		pdf.SetTextColor(0xDD, 0, 0)
+		pdf.CellFormat(0, 22, "PRELIMINARY", "", 1, "C", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	}
+	if r.UniqueCallSigns != 0 {
+		pdf.SetFont("Helvetica", "B", 14)
+		pdf.CellFormat(0, 20, fmt.Sprintf("%d Unique Call Signs", r.UniqueCallSigns), "", 1, "C", false, 0, "")
+		if r.UniqueCallSignsWeek != 0 {
+			pdf.SetFont("Helvetica", "B", 12)
+			pdf.SetTextColor(0x88, 0x88, 0x88)
+			pdf.CellFormat(0, 16, fmt.Sprintf("%d for the week", r.UniqueCallSignsWeek), "", 1, "C", false, 0, "")
+		}
+	}
+	pdf.Ln(pdfLineHeight)
+}
+
+func (r *Report) pdfExpectsResults(pdf *fpdf.Fpdf, tr func(string) string) {
+	var hasModel, text string
+
+	if r.HasModel {
+		hasModel = "copy of model "
+	}
+	text = fmt.Sprintf("%s%s sent to %s between %s and %s",
+		hasModel, english.Conjoin(r.MessageTypes, "or"), r.SentTo, r.SentAfter, r.SentBefore)
+	if r.NotSentFrom != "" {
+		text += fmt.Sprintf("; not sent from %s", r.NotSentFrom)
+	}
+	text += "."
+	if r.Modified {
+		text += "  Expectations were modified during session; some early messages may have been evaluated against different expectations."
+	}
+	pdfHeading(pdf, "Expectations")
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.MultiCell(0, pdfLineHeight, tr(text), "", "L", false)
+	pdf.Ln(pdfLineHeight / 2)
+	pdfHeading(pdf, "Results")
+	if r.ValidCount+r.InvalidCount+r.ReplacedCount+r.DroppedCount == 0 {
+		pdfKeyValue(pdf, tr, "Messages", "0")
+	}
+	if r.ValidCount != 0 {
+		pdfKeyValue(pdf, tr, "Counted", strconv.Itoa(r.ValidCount))
+		pdfKeyValue(pdf, tr, "Average Score", fmt.Sprintf("%d%%", r.AverageValidScore))
+	}
+	if r.InvalidCount != 0 {
+		pdfKeyValue(pdf, tr, "Not Counted", strconv.Itoa(r.InvalidCount))
+	}
+	if r.ReplacedCount != 0 {
+		pdfKeyValue(pdf, tr, "Duplicate", strconv.Itoa(r.ReplacedCount))
+	}
+	if r.DroppedCount != 0 {
+		pdfKeyValue(pdf, tr, "Receipts", strconv.Itoa(r.DroppedCount))
+	}
+	if r.FeedbackSent != 0 {
+		pdfKeyValue(pdf, tr, "Responses Read", fmt.Sprintf("%d/%d", r.FeedbackRead, r.FeedbackSent))
+	}
+	pdf.Ln(pdfLineHeight)
+}
+
+func (r *Report) pdfMessages(pdf *fpdf.Fpdf, tr func(string) string) {
+	var (
+		hasMultiple bool
+		rows        [][]string
+		columns     = []pdfColumn{
+			{"Call Sign", 90, "L"}, {"Source", 70, "L"}, {"Juris.", 45, "L"}, {"Score", 45, "R"}, {"Summary", 0, "L"},
+		}
+	)
+	if len(r.Messages) == 0 {
+		return
+	}
+	for _, m := range r.Messages {
+		var source = m.Source
+		if m.Multiple {
+			source, hasMultiple = source+"*", true
+		}
+		rows = append(rows, []string{m.Prefix + m.Suffix, source, m.Jurisdiction, fmt.Sprintf("%d%%", m.Score), m.Summary})
+	}
+	pdfHeading(pdf, "Messages")
+	pdfTable(pdf, tr, columns, rows)
+	if hasMultiple {
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.CellFormat(0, pdfLineHeight, "* multiple messages from this address; only the last one counts", "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(pdfLineHeight)
+}
+
+func (r *Report) pdfStatistics(pdf *fpdf.Fpdf, tr func(string) string) {
+	if len(r.Sources) != 0 {
+		var rows [][]string
+		for _, source := range r.Sources {
+			var name = source.Name
+			if source.SimulatedDown {
+				name += " (simulated outage)"
+			}
+			rows = append(rows, []string{name, strconv.Itoa(source.Count)})
+		}
+		pdfHeading(pdf, "Sent From")
+		pdfTable(pdf, tr, []pdfColumn{{"Source", 200, "L"}, {"Count", 50, "R"}}, rows)
+		pdf.Ln(pdfLineHeight)
+	}
+	for _, section := range []struct {
+		title  string
+		column string
+		counts []*Count
+	}{
+		{"Jurisdiction", "Jurisdiction", r.Jurisdictions},
+		{"Message Type", "Type", r.MTypeCounts},
+		{"Problems", "Problem", r.Problems},
+	} {
+		var rows [][]string
+		if len(section.counts) == 0 {
+			continue
+		}
+		for _, c := range section.counts {
+			rows = append(rows, []string{c.Name, strconv.Itoa(c.Count)})
+		}
+		pdfHeading(pdf, section.title)
+		pdfTable(pdf, tr, []pdfColumn{{section.column, 300, "L"}, {"Count", 50, "R"}}, rows)
+		pdf.Ln(pdfLineHeight)
+	}
+}
+
+// pdfHeading writes a section heading.  It starts a new page if there isn't
+// room for the heading and a few lines of the section on the current page.
+func pdfHeading(pdf *fpdf.Fpdf, heading string) {
+	_, height := pdf.GetPageSize()
+	if pdf.GetY()+5*pdfLineHeight > height-pdfMargin {
+		pdf.AddPage()
+	}
+	pdf.SetFont("Helvetica", "B", 13)
+	pdf.SetTextColor(0x44, 0x44, 0x44)
+	pdf.CellFormat(0, 18, heading, "", 1, "L", false, 0, "")
+}
+
+// pdfKeyValue writes a line containing a key and value.
+func pdfKeyValue(pdf *fpdf.Fpdf, tr func(string) string, key, value string) {
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.SetTextColor(0x66, 0x66, 0x66)
+	pdf.CellFormat(120, pdfLineHeight, tr(key), "", 0, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+	pdf.CellFormat(50, pdfLineHeight, tr(value), "", 1, "R", false, 0, "")
+}
+
+// pdfTable writes a table.  The column headings are repeated at the top of
+// each page that the table spans.  A column width of zero extends the column
+// to the right margin.  Cell text that is too wide for its column is wrapped
+// onto additional lines.
+func pdfTable(pdf *fpdf.Fpdf, tr func(string) string, columns []pdfColumn, rows [][]string) {
+	pagewidth, height := pdf.GetPageSize()
+	left, _, right, _ := pdf.GetMargins()
+	var tableWidth float64
+	widths := make([]float64, len(columns))
+	for i, col := range columns {
+		if widths[i] = col.width; widths[i] == 0 {
+			widths[i] = pagewidth - right - left - tableWidth
+		}
+		tableWidth += widths[i]
+	}
+	headings := func() {
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.SetTextColor(0x66, 0x66, 0x66)
+		for i, col := range columns {
+			pdf.CellFormat(widths[i], pdfLineHeight, col.heading, "B", 0, col.align, false, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.SetTextColor(0, 0, 0)
+	}
+	headings()
+	for i, row := range rows {
+		// Wrap the text of each cell to its column width; the row is
+		// as tall as its tallest cell.
+		var lines = make([][][]byte, len(columns))
+		var rowHeight float64 = pdfLineHeight
+		for j := range columns {
+			lines[j] = pdf.SplitLines([]byte(tr(row[j])), widths[j])
+			rowHeight = max(rowHeight, float64(len(lines[j]))*pdfLineHeight)
+		}
+		if pdf.GetY()+rowHeight > height-pdfMargin {
+			pdf.AddPage()
+			headings()
+		}
+		x, y := left, pdf.GetY()
+		if i%2 == 1 {
+			pdf.SetFillColor(0xF0, 0xF0, 0xF0)
+			pdf.Rect(x, y, tableWidth, rowHeight, "F")
+		}
+		for j, col := range columns {
+			for k, line := range lines[j] {
+				pdf.SetXY(x, y+float64(k)*pdfLineHeight)
+				pdf.CellFormat(widths[j], pdfLineHeight, string(line), "", 0, col.align, false, 0, "")
+			}
+			x += widths[j]
+		}
+		pdf.SetXY(left, y+rowHeight)
+	}
+}
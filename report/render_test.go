@@ -0,0 +1,166 @@
+package report
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rothskeller/wppsvr/config"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+var goldenFull = Report{
+	SessionName:         "SVECS Net",
+	SessionDate:         "Tuesday, April 19, 2022",
+	Preliminary:         true,
+	MessageTypes:        []string{"OA Municipal Status", "plain text"},
+	SentTo:              "PKTTUE at W2XSC",
+	SentBefore:          "Tue 2022-04-19 20:00",
+	SentAfter:           "Wed 2022-04-13 00:00",
+	NotSentFrom:         "W3XSC",
+	Modified:            true,
+	ValidCount:          12,
+	InvalidCount:        1,
+	ReplacedCount:       2,
+	DroppedCount:        3,
+	AverageValidScore:   86,
+	FeedbackSent:        13,
+	FeedbackRead:        9,
+	UniqueCallSigns:     12,
+	UniqueCallSignsWeek: 15,
+	Sources: []*Source{
+		{Name: "W1XSC", Count: 10},
+		{Name: "W3XSC", Count: 1, SimulatedDown: true},
+		{Name: "Winlink", Count: 1},
+	},
+	Jurisdictions: []*Count{{Name: "SNY", Count: 11}, {Name: "???", Count: 1}},
+	MTypeCounts:   []*Count{{Name: "OA Municipal Status", Count: 3}, {Name: "plain text", Count: 9}},
+	Problems:      []*Count{{Name: "message not from jurisdiction's assigned BBS", Count: 2}, {Name: "incorrect message number format", Count: 1}},
+	Messages: []*Message{
+		{ID: "TST-003P", Hash: "abc", FromCallSign: "KC6RSC", Prefix: "KC", Suffix: "6RSC", Source: "W1XSC", Multiple: true, Jurisdiction: "SNY", Score: 100, Summary: "OK"},
+		{ID: "TST-005P", Hash: "def", FromCallSign: "AA6BT", Prefix: "AA", Suffix: "6BT", Source: "W3XSC", Jurisdiction: "???", Score: 77, Summary: "multiple issues"},
+		{ID: "TST-006P", Hash: "ghi", FromCallSign: "K6SNY", Prefix: "K6", Suffix: "SNY", Source: "Winlink", Jurisdiction: "SNY", Score: 95, Summary: "minor issue"},
+		{ID: "TST-007P", Hash: "jkl", Prefix: "pkt", Suffix: "test+net", Source: "Email", Score: 0, Summary: "not a check-in"},
+	},
+	Trends: []*TrendPoint{
+		{Date: "4/5", UniqueCallSigns: 9, AverageScore: 81, BBS: 8, Winlink: 1},
+		{Date: "4/12", UniqueCallSigns: 11, AverageScore: 90, BBS: 9, Winlink: 1, Email: 1},
+		{Date: "4/19", UniqueCallSigns: 12, AverageScore: 86, BBS: 11, Winlink: 1},
+	},
+	Exercise: &Exercise{Sent: 14, Replied: 11, AverageTurnaround: "26h4m10s"},
+	Drill: &Drill{
+		TriggerTime: "Tue 2022-04-19 19:00",
+		Latencies:   []*Count{{Name: "≤15m", Count: 2}, {Name: "≤30m", Count: 1}, {Name: "≤60m", Count: 0}, {Name: "≤120m", Count: 1}, {Name: ">120m", Count: 0}},
+		Fastest:     []*Responder{{CallSign: "KC6RSC", Latency: "4m12s"}, {CallSign: "K6SNY", Latency: "11m3s"}},
+	},
+	GenerationInfo: "This report was generated on Tuesday, April 19, 2022 at 20:01 by wppsvr version (devel).  Messages were retrieved from W2XSC as PKTTUE at 20:00.",
+	Missed:         []string{"KW6W", "W6XYZ"},
+	Jurisdiction:   "SNY",
+	Comparison: &Comparison{
+		PreviousSessionDate:     "Tuesday, April 12, 2022",
+		PreviousUniqueCallSigns: 11,
+		NewCallSigns:            []string{"K6SNY", "KC6RSC"},
+		MissingCallSigns:        []string{"KW6W"},
+	},
+}
+
+var goldenEmpty = Report{
+	SessionName:    "SPECS Net",
+	SessionDate:    "Monday, April 18, 2022",
+	MessageTypes:   []string{"plain text"},
+	HasModel:       true,
+	SentTo:         "PKTMON at W1XSC or W2XSC",
+	SentBefore:     "Mon 2022-04-18 20:00",
+	SentAfter:      "Tue 2022-04-12 00:00",
+	GenerationInfo: "This report was generated on Monday, April 18, 2022 at 20:01 by wppsvr version (devel).",
+}
+
+// TestRenderGolden verifies that the report renderings match the golden files
+// in testdata.  Run with -update to regenerate them.
+func TestRenderGolden(t *testing.T) {
+	config.SetConfig(&config.Config{
+		ServerURL: "https://packet.example.org",
+		SMTP:      &config.SMTPConfig{From: "Packet Practice <packet@example.org>"},
+	})
+	for name, r := range map[string]*Report{"full": &goldenFull, "empty": &goldenEmpty} {
+		var sb strings.Builder
+
+		r.RenderHTMLBody(&sb, "KC6RSC")
+		checkGolden(t, name+".txt", r.RenderPlainText())
+		checkGolden(t, name+".html", sb.String())
+		checkGolden(t, name+".eml", r.RenderEmail("someone@example.org", false))
+	}
+}
+
+func checkGolden(t *testing.T, name, actual string) {
+	filename := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(filename, []byte(actual), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	expected, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual != string(expected) {
+		t.Errorf("%s: incorrect output:\n%s", name, actual)
+	}
+}
+
+// TestTemplateOverride verifies that a template in the working directory
+// overrides the default one.
+func TestTemplateOverride(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(cwd)
+	if err = os.WriteFile(textTemplateName, []byte("{{.SessionName}} on {{.SessionDate}}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if actual := goldenEmpty.RenderPlainText(); actual != "SPECS Net on Monday, April 18, 2022\n" {
+		t.Errorf("incorrect output:\n%s", actual)
+	}
+}
+
+// TestMissedPrivileged verifies that the list of regulars who missed the
+// session is shown only to privileged viewers.
+func TestMissedPrivileged(t *testing.T) {
+	var privileged, unprivileged strings.Builder
+
+	goldenFull.RenderHTMLBody(&privileged, "")
+	goldenFull.RenderHTMLBody(&unprivileged, "KC6RSC")
+	if !strings.Contains(privileged.String(), `<div id="missed">KW6W W6XYZ</div>`) {
+		t.Error("missed list not shown to privileged viewer")
+	}
+	if strings.Contains(unprivileged.String(), "KW6W W6XYZ") {
+		t.Error("missed list shown to unprivileged viewer")
+	}
+}
+
+func TestRenderPDF(t *testing.T) {
+	// Add a message whose summary is too long for its table cell, to
+	// exercise the wrapping.
+	var long = goldenFull
+	long.Messages = append(long.Messages[:len(long.Messages):len(long.Messages)], &Message{
+		ID: "TST-008P", Hash: "mno", FromCallSign: "KW6W", Prefix: "KW", Suffix: "6W", Source: "W1XSC", Jurisdiction: "SNY", Score: 50,
+		Summary: strings.Repeat("a very long summary of many problems — ", 8),
+	})
+	for name, r := range map[string]*Report{"full": &long, "empty": &goldenEmpty} {
+		pdf, err := r.RenderPDF()
+		if err != nil {
+			t.Errorf("%s: RenderPDF: %s", name, err)
+		} else if !bytes.HasPrefix(pdf, []byte("%PDF")) {
+			t.Errorf("%s: RenderPDF output is not a PDF", name)
+		}
+	}
+}
@@ -10,66 +10,138 @@ import (
 // Store is an interface covering those methods of store.Store that are used in
 // generating reports.
 type Store interface {
+	GetExerciseMessages(int) []*store.ExerciseMessage
 	GetSessionMessages(int) []*store.Message
+	GetSessionResponses(int) []*store.Response
 	GetSessions(start, end time.Time) []*store.Session
 	UpdateSession(*store.Session)
 	NextMessageID(string) string
+	QueueMessage(*store.OutboxMessage)
 }
 
 // A Report contains all of the information that goes into a report about a
 // practice session.  (This can include information from multiple sessions when
 // a weekly summary is part of the report.)
 type Report struct {
-	SessionName         string
-	SessionDate         string
-	Preliminary         bool
-	MessageTypes        []string
-	HasModel            bool
-	SentTo              string
-	SentBefore          string
-	SentAfter           string
-	NotSentFrom         string
-	Modified            bool
-	ValidCount          int
-	InvalidCount        int
-	ReplacedCount       int
-	DroppedCount        int
-	AverageValidScore   int
-	uniqueCallSigns     map[string]struct{}
-	UniqueCallSigns     int
-	UniqueCallSignsWeek int
-	Sources             []*Source
-	Jurisdictions       []*Count
-	MTypeCounts         []*Count
-	Messages            []*Message
-	Participants        []string
-	GenerationInfo      string
+	SessionName         string     `json:"sessionName,omitempty"`
+	SessionDate         string     `json:"sessionDate,omitempty"`
+	Preliminary         bool       `json:"preliminary,omitempty"`
+	MessageTypes        []string   `json:"messageTypes,omitempty"`
+	HasModel            bool       `json:"hasModel,omitempty"`
+	SentTo              string     `json:"sentTo,omitempty"`
+	SentBefore          string     `json:"sentBefore,omitempty"`
+	SentAfter           string     `json:"sentAfter,omitempty"`
+	NotSentFrom         string     `json:"notSentFrom,omitempty"`
+	Modified            bool       `json:"modified,omitempty"`
+	ValidCount          int        `json:"validCount"`
+	InvalidCount        int        `json:"invalidCount,omitempty"`
+	ReplacedCount       int        `json:"replacedCount,omitempty"`
+	DroppedCount        int        `json:"droppedCount,omitempty"`
+	AverageValidScore   int        `json:"averageValidScore"`
+	FeedbackSent        int        `json:"feedbackSent,omitempty"`
+	FeedbackRead        int        `json:"feedbackRead,omitempty"`
+	UniqueCallSigns     int        `json:"uniqueCallSigns,omitempty"`
+	UniqueCallSignsWeek int        `json:"uniqueCallSignsWeek,omitempty"`
+	Sources             []*Source  `json:"sources,omitempty"`
+	Jurisdictions       []*Count   `json:"jurisdictions,omitempty"`
+	MTypeCounts         []*Count   `json:"messageTypeCounts,omitempty"`
+	Problems            []*Count   `json:"problems,omitempty"`
+	Messages            []*Message `json:"messages,omitempty"`
+	Participants        []string   `json:"-"`
+	GenerationInfo      string     `json:"generationInfo,omitempty"`
+	// Missed lists the regular participants of the net who did not check
+	// in to this session.  It is set only by AddMissed, and is shown only
+	// to privileged viewers.
+	Missed []string `json:"-"`
+	// Trends gives the history of the net over recent weeks, ending with
+	// this session.  It is not set in jurisdiction-scoped reports.
+	Trends []*TrendPoint `json:"trends,omitempty"`
+	// Exercise gives the replies to a two-way exercise session.  It is
+	// set only for two-way exercise sessions.
+	Exercise *Exercise `json:"exercise,omitempty"`
+	// Drill gives the response times of a timed drill session.  It is
+	// set only for timed drill sessions.
+	Drill *Drill `json:"drill,omitempty"`
+	// Jurisdiction and Comparison are set only in jurisdiction-scoped
+	// reports.
+	Jurisdiction string      `json:"jurisdiction,omitempty"`
+	Comparison   *Comparison `json:"comparison,omitempty"`
+
+	uniqueCallSigns map[string]struct{}
+	missedAddresses map[string]string
+	sessionEnd      time.Time
 }
 
 // A Source contains the information about a single source of messages in a
 // Report.
 type Source struct {
-	Name          string
-	Count         int
-	SimulatedDown bool
+	Name          string `json:"name,omitempty"`
+	Count         int    `json:"count"`
+	SimulatedDown bool   `json:"simulatedDown,omitempty"`
 }
 
 // A Count contains a name/count pair.
 type Count struct {
-	Name  string
-	Count int
+	Name  string `json:"name,omitempty"`
+	Count int    `json:"count"`
+}
+
+// A TrendPoint contains the statistics for a single session in the Trends of a
+// Report.  BBS, Winlink, and Email count the counted messages from each kind of
+// source.
+type TrendPoint struct {
+	Date            string `json:"date"`
+	UniqueCallSigns int    `json:"uniqueCallSigns"`
+	AverageScore    int    `json:"averageScore"`
+	BBS             int    `json:"bbs"`
+	Winlink         int    `json:"winlink"`
+	Email           int    `json:"email"`
+}
+
+// An Exercise contains the reply statistics for a two-way exercise session:
+// the number of exercise messages sent, the number of participants who replied
+// to them (with counted messages), and their average turnaround time.
+type Exercise struct {
+	Sent              int    `json:"sent"`
+	Replied           int    `json:"replied"`
+	AverageTurnaround string `json:"averageTurnaround,omitempty"`
+}
+
+// A Drill contains the response time statistics for a timed drill session.
+// Latencies is a histogram of the response times of the counted messages, and
+// Fastest lists the fastest responders.
+type Drill struct {
+	TriggerTime string       `json:"triggerTime"`
+	Latencies   []*Count     `json:"latencies"`
+	Fastest     []*Responder `json:"fastest,omitempty"`
+}
+
+// A Responder gives the response time of a single responder in a Drill.
+type Responder struct {
+	CallSign string `json:"callSign"`
+	Latency  string `json:"latency"`
+}
+
+// A Comparison compares the participants in a jurisdiction-scoped report with
+// those from the same jurisdiction in the previous session of the same net.
+type Comparison struct {
+	PreviousSessionDate     string   `json:"previousSessionDate,omitempty"`
+	PreviousUniqueCallSigns int      `json:"previousUniqueCallSigns"`
+	NewCallSigns            []string `json:"newCallSigns,omitempty"`
+	MissingCallSigns        []string `json:"missingCallSigns,omitempty"`
 }
 
 // A Message contains the information about a single message in a Report.
 type Message struct {
-	ID           string
-	Hash         string
-	FromCallSign string
-	Prefix       string
-	Suffix       string
-	Source       string
-	Multiple     bool
-	Jurisdiction string
-	Score        int
-	Summary      string
+	ID           string   `json:"id,omitempty"`
+	Hash         string   `json:"-"`
+	FromCallSign string   `json:"fromCallSign,omitempty"`
+	Prefix       string   `json:"prefix,omitempty"`
+	Suffix       string   `json:"suffix,omitempty"`
+	Source       string   `json:"source,omitempty"`
+	Multiple     bool     `json:"multiple,omitempty"`
+	Jurisdiction string   `json:"jurisdiction,omitempty"`
+	Score        int      `json:"score"`
+	Summary      string   `json:"summary,omitempty"`
+	Problems     []string `json:"problems,omitempty"`
 }
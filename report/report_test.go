@@ -1,6 +1,8 @@
 package report
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -85,6 +87,7 @@ func (fakeStore) GetSessionMessages(sessionID int) []*store.Message {
 				MessageType:  "plain",
 				Score:        77,
 				Summary:      "multiple issues",
+				Problems:     []string{"FromBBSDown", "MsgNumFormat"},
 			},
 		}
 	default:
@@ -92,16 +95,74 @@ func (fakeStore) GetSessionMessages(sessionID int) []*store.Message {
 	}
 }
 
-func (fakeStore) GetSessions(start, end time.Time) []*store.Session {
-	if !start.Equal(time.Date(2022, 4, 17, 0, 0, 0, 0, time.Local)) ||
-		!end.Equal(time.Date(2022, 4, 24, 0, 0, 0, 0, time.Local)) {
-		panic("wrong dates for GetSessionsEnding")
+func (fakeStore) GetSessions(start, end time.Time) (list []*store.Session) {
+	for _, session := range []*store.Session{&fakeSession1, &fakeSession2, &fakeSession3} {
+		if !session.End.Before(start) && session.End.Before(end) {
+			list = append(list, session)
+		}
+	}
+	return list
+}
+
+func (fakeStore) GetSessionResponses(int) []*store.Response        { return nil }
+func (fakeStore) GetExerciseMessages(int) []*store.ExerciseMessage { return nil }
+func (fakeStore) UpdateSession(*store.Session)                     { panic("not implemented") }
+func (fakeStore) NextMessageID(string) string                      { panic("not implemented") }
+func (fakeStore) QueueMessage(*store.OutboxMessage)                { panic("not implemented") }
+
+// historyStore is a fake store holding a set of sessions and their messages,
+// for testing the parts of report generation that look across sessions.  It
+// also records the messages queued in its outbox.
+type historyStore struct {
+	sessions []*store.Session
+	messages map[int][]*store.Message
+	queued   []*store.OutboxMessage
+	msgnum   int
+}
+
+func (h *historyStore) GetSessions(start, end time.Time) (list []*store.Session) {
+	for _, session := range h.sessions {
+		if !session.End.Before(start) && session.End.Before(end) {
+			list = append(list, session)
+		}
 	}
-	return []*store.Session{&fakeSession1, &fakeSession2, &fakeSession3}
+	return list
 }
 
-func (fakeStore) UpdateSession(*store.Session) { panic("not implemented") }
-func (fakeStore) NextMessageID(string) string  { panic("not implemented") }
+func (h *historyStore) GetSessionMessages(sessionID int) []*store.Message {
+	return append([]*store.Message(nil), h.messages[sessionID]...)
+}
+
+func (h *historyStore) GetSessionResponses(int) []*store.Response        { return nil }
+func (h *historyStore) GetExerciseMessages(int) []*store.ExerciseMessage { return nil }
+func (h *historyStore) UpdateSession(*store.Session)                     { panic("not implemented") }
+
+func (h *historyStore) NextMessageID(prefix string) string {
+	h.msgnum++
+	return fmt.Sprintf("%s-%03dP", prefix, 100+h.msgnum)
+}
+
+func (h *historyStore) QueueMessage(om *store.OutboxMessage) {
+	h.queued = append(h.queued, om)
+}
+
+// add adds a session to the historyStore, with the specified messages.
+func (h *historyStore) add(session *store.Session, messages ...*store.Message) {
+	h.sessions = append(h.sessions, session)
+	if h.messages == nil {
+		h.messages = make(map[int][]*store.Message)
+	}
+	for _, m := range messages {
+		m.Session = session.ID
+		if m.FromAddress == "" {
+			m.FromAddress = strings.ToLower(m.FromCallSign) + "@w1xsc.ampr.org"
+		}
+		if m.MessageType == "" {
+			m.MessageType = "plain"
+		}
+	}
+	h.messages[session.ID] = messages
+}
 
 const expected = `==== SCCo ARES/RACES Packet Practice Report
 ==== for SVECS Net on Tuesday, April 19, 2022
@@ -133,6 +194,10 @@ KC6RSC  @W1XSC*  (SNY)  100%  OK
 ---- MESSAGE TYPE
 2  plain
 
+---- PROBLEMS
+1  incorrect message number format
+1  message from incorrect BBS (simulated outage)
+
 This report was generated on Tuesday, April 19, 2022 at 20:00 by wppsvr.
 `
 
@@ -143,3 +208,27 @@ func TestReport(t *testing.T) {
 		t.Errorf("incorrect report output:\n%s", actual)
 	}
 }
+
+const expectedCSV = `Message ID,Call Sign,From,Source,Multiple,Jurisdiction,Score,Summary,Problems
+TST-005P,AA6BT,AA6BT,W3XSC,,???,77,multiple issues,FromBBSDown; MsgNumFormat
+TST-004P,KC6RSC,KC6RSC,W1XSC,yes,SNY,100,OK,
+
+Source,Count,Simulated Down
+W1XSC,1,
+W3XSC,1,yes
+
+Jurisdiction,Count
+SNY,1
+???,1
+
+Message Type,Count
+plain,2
+`
+
+func TestReportCSV(t *testing.T) {
+	xscmsg.Register()
+	actual := Generate(fakeStore{}, &fakeSession3).RenderCSV()
+	if actual != expectedCSV {
+		t.Errorf("incorrect CSV output:\n%s", actual)
+	}
+}
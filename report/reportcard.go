@@ -0,0 +1,210 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rothskeller/packet/envelope"
+	"github.com/rothskeller/packet/jnos"
+	"github.com/rothskeller/packet/message"
+	"github.com/rothskeller/wppsvr/analyze"
+	"github.com/rothskeller/wppsvr/config"
+	"github.com/rothskeller/wppsvr/store"
+)
+
+// streakLookback is how far back we look for check-ins when computing a
+// participant's streak.
+const streakLookback = 2 // years
+
+// A ReportCard contains the information that goes into a periodic report card
+// for a single participant.
+type ReportCard struct {
+	CallSign string
+	Period   string
+	// Email is the address to which the report card should be sent by
+	// SMTP.  If it is empty, the report card is sent to Address, the
+	// participant's BBS mailbox, taken from their most recent check-in.
+	Email    string
+	Address  string
+	CheckIns []*ReportCardCheckIn
+	// Counted is the number of check-ins that were counted, and
+	// AverageScore is their average score.
+	Counted      int
+	AverageScore int
+	// Problems lists the problems that appeared in more than one of the
+	// participant's check-ins during the period.
+	Problems []*Count
+	// Streak is the number of consecutive weeks, ending with the most
+	// recent week of the period that had practice sessions, in which the
+	// participant had a counted check-in.
+	Streak int
+}
+
+// A ReportCardCheckIn describes a single check-in in a ReportCard.
+type ReportCardCheckIn struct {
+	Date        string
+	Session     string
+	MessageType string
+	Score       int
+	Summary     string
+}
+
+// GenerateReportCards generates report cards for the specified participants,
+// covering the sessions ending in the specified time range (inclusive start,
+// exclusive end).
+func GenerateReportCards(st Store, start, end time.Time, participants []*store.Participant) (cards []*ReportCard) {
+	var (
+		bycall   = make(map[string]*ReportCard)
+		problems = make(map[string]map[string]int)
+		weeks    []time.Time
+		counted  = make(map[time.Time]map[string]bool)
+		period   = summaryPeriod(start, end)
+	)
+	for _, p := range participants {
+		var rc = ReportCard{CallSign: p.CallSign, Period: period, Email: p.Email}
+		bycall[p.CallSign] = &rc
+		problems[p.CallSign] = make(map[string]int)
+		cards = append(cards, &rc)
+	}
+	for _, session := range summarySessions(st, end.AddDate(-streakLookback, 0, 0), end) {
+		week := session.End.AddDate(0, 0, -int(session.End.Weekday()))
+		week = time.Date(week.Year(), week.Month(), week.Day(), 0, 0, 0, 0, time.Local)
+		if len(weeks) == 0 || !weeks[len(weeks)-1].Equal(week) {
+			weeks = append(weeks, week)
+			counted[week] = make(map[string]bool)
+		}
+		messages, _ := removeReplaced(removeDroppedMessages(st.GetSessionMessages(session.ID)))
+		for _, m := range messages {
+			rc := bycall[m.FromCallSign]
+			if rc == nil {
+				continue
+			}
+			rc.Address = m.FromAddress
+			if m.Score != 0 {
+				counted[week][m.FromCallSign] = true
+			}
+			if session.End.Before(start) {
+				continue
+			}
+			rc.CheckIns = append(rc.CheckIns, &ReportCardCheckIn{
+				Date:        session.End.Format("2006-01-02"),
+				Session:     session.Name,
+				MessageType: m.MessageType,
+				Score:       m.Score,
+				Summary:     m.Summary,
+			})
+			if m.Score != 0 {
+				rc.Counted++
+				rc.AverageScore += m.Score
+			}
+			for _, code := range m.Problems {
+				problems[m.FromCallSign][code]++
+			}
+		}
+	}
+	for _, rc := range cards {
+		if rc.Counted != 0 {
+			rc.AverageScore /= rc.Counted
+		}
+		for code, count := range problems[rc.CallSign] {
+			if count > 1 {
+				rc.Problems = append(rc.Problems, &Count{Name: analyze.ProblemLabel(code), Count: count})
+			}
+		}
+		sort.Slice(rc.Problems, func(i, j int) bool {
+			if rc.Problems[i].Count != rc.Problems[j].Count {
+				return rc.Problems[i].Count > rc.Problems[j].Count
+			}
+			return rc.Problems[i].Name < rc.Problems[j].Name
+		})
+		for i := len(weeks) - 1; i >= 0 && counted[weeks[i]][rc.CallSign]; i-- {
+			rc.Streak++
+		}
+	}
+	return cards
+}
+
+// RenderPlainText renders a report card in plain text format.
+func (rc *ReportCard) RenderPlainText() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "==== SCCo ARES/RACES Packet Practice Report Card\n==== for %s, %s\n\n", rc.CallSign, rc.Period)
+	if len(rc.CheckIns) == 0 {
+		sb.WriteString("You did not check in to any packet practice sessions during this period.\n\n")
+	} else {
+		fmt.Fprintf(&sb, "%s, %d counted, %d%% average score\n", plural(len(rc.CheckIns), "check-in"), rc.Counted, rc.AverageScore)
+	}
+	fmt.Fprintf(&sb, "Current streak: %s\n\n", plural(rc.Streak, "week"))
+	if len(rc.CheckIns) != 0 {
+		var col1, col2, col3 []string
+		for _, ci := range rc.CheckIns {
+			col1 = append(col1, ci.Date)
+			col2 = append(col2, strconv.Itoa(ci.Score)+"%")
+			col3 = append(col3, fmt.Sprintf("%s (%s)  %s", ci.Session, ci.MessageType, ci.Summary))
+		}
+		rightAlign(col2)
+		sb.WriteString("---- CHECK-INS\n")
+		for _, line := range sideBySide(sideBySide(col1, col2, 2), col3, 2) {
+			sb.WriteString(line)
+			sb.WriteByte('\n')
+		}
+		sb.WriteByte('\n')
+	}
+	if len(rc.Problems) != 0 {
+		sb.WriteString("---- RECURRING PROBLEMS\n")
+		for _, p := range rc.Problems {
+			fmt.Fprintf(&sb, "%s (%s)\n", p.Name, plural(p.Count, "time"))
+		}
+		sb.WriteByte('\n')
+	}
+	fmt.Fprintf(&sb, "To change how you receive report cards, visit %s/profile\n", config.Get().ServerURL)
+	return sb.String()
+}
+
+// RenderEmail renders the report card in a form suitable for emailing.  The
+// parameter is the To: line of the email.
+func (rc *ReportCard) RenderEmail(to string) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "From: %s\r\nTo: %s\r\nSubject: SCCo Packet Practice Report Card for %s\r\n", config.Get().SMTP.From, to, rc.Period)
+	sb.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	sb.WriteString(strings.ReplaceAll(rc.RenderPlainText(), "\n", "\r\n"))
+	return sb.String()
+}
+
+// Send sends the report card to the participant.  If they registered an email
+// address, it is sent there via SMTP.  Otherwise, it is sent to their BBS
+// mailbox through the supplied open BBS connection, using a message number
+// with the specified prefix.
+func (rc *ReportCard) Send(st Store, conn *jnos.Conn, prefix string) error {
+	if rc.Email != "" {
+		return sendEmail([]string{rc.Email}, rc.RenderEmail)
+	}
+	if rc.Address == "" {
+		return fmt.Errorf("no known address for %s", rc.CallSign)
+	}
+	if conn == nil {
+		return fmt.Errorf("no BBS connection to send to %s", rc.Address)
+	}
+	subject := message.EncodeSubject(st.NextMessageID(prefix), "ROUTINE", "", "SCCo Packet Practice Report Card")
+	body := new(envelope.Envelope).RenderBody(rc.RenderPlainText())
+	return conn.Send(subject, body, rc.Address)
+}
+
+// SendEmailConfirmation sends a message to the specified email address asking
+// its owner to confirm, by following the specified link, that the report cards
+// of the participant with the specified call sign should be sent there.
+func SendEmailConfirmation(email, callsign, link string) error {
+	return sendEmail([]string{email}, func(to string) string {
+		var sb strings.Builder
+
+		fmt.Fprintf(&sb, "From: %s\r\nTo: %s\r\nSubject: Confirm SCCo Packet Practice Report Cards\r\n", config.Get().SMTP.From, to)
+		sb.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+		fmt.Fprintf(&sb, "%s has asked for their SCCo packet practice report cards to be sent to this\r\naddress.  To confirm, visit\r\n    %s\r\n", callsign, link)
+		sb.WriteString("If you didn't expect this message, you can ignore it; nothing will be sent\r\nhere unless the link above is followed.\r\n")
+		return sb.String()
+	})
+}
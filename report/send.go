@@ -6,6 +6,7 @@ import (
 	"net"
 	"net/mail"
 	"net/smtp"
+	"sort"
 	"strings"
 
 	"github.com/rothskeller/packet/envelope"
@@ -17,8 +18,8 @@ import (
 
 // Send generates the report for the session and sends it to all designated
 // recipients, through the supplied open BBS connection and/or via SMTP.  It
-// also stores the report in the session.
-func Send(st Store, conn *jnos.Conn, session *store.Session) {
+// also stores the report in the session, and returns it.
+func Send(st Store, conn *jnos.Conn, session *store.Session) *Report {
 	report := Generate(st, session)
 	sendTo := session.ReportToText
 	if session.Flags&store.ReportToSenders != 0 {
@@ -35,15 +36,64 @@ func Send(st Store, conn *jnos.Conn, session *store.Session) {
 		conn.Send(subject, body, addr)
 	}
 	if len(session.ReportToHTML) != 0 {
-		if err := report.SendHTML(session.ReportToHTML); err != nil {
+		if err := report.SendHTML(session.ReportToHTML, false); err != nil {
 			log.Printf("ERROR: %s", err)
 		}
 	}
+	if session.Flags&store.ExcludeFromWeek == 0 {
+		sendJurisdictionReports(st, session)
+	}
+	if session.Flags&store.SendMissedYou != 0 {
+		report.AddMissed(st, session)
+		sendMissedYou(st, session, report)
+	}
 	log.Printf("Sent report for %s on %s.", session.Name, session.End.Format("2006-01-02"))
+	return report
+}
+
+// Synopsis returns a one-line summary of the report statistics.
+func (r *Report) Synopsis() string {
+	if r.ValidCount == 0 {
+		return "no counted messages"
+	}
+	return fmt.Sprintf("%s, %s, %d%% average score",
+		plural(r.UniqueCallSigns, "unique call sign"), plural(r.ValidCount, "counted message"), r.AverageValidScore)
+}
+
+// sendJurisdictionReports sends the jurisdiction-scoped reports for the
+// session to the distribution lists configured for each jurisdiction.  No
+// report is sent for a jurisdiction from which nobody checked in.
+func sendJurisdictionReports(st Store, session *store.Session) {
+	var codes []string
+
+	for code := range config.Get().JurisdictionReports {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		to := append([]string(nil), config.Get().JurisdictionReports[code]...)
+		if len(to) == 0 {
+			continue
+		}
+		rep := GenerateJurisdiction(st, session, code)
+		if rep.ValidCount+rep.InvalidCount == 0 {
+			continue
+		}
+		if err := rep.SendHTML(to, false); err != nil {
+			log.Printf("ERROR: sending %s report: %s", code, err)
+		}
+	}
+}
+
+// SendHTML sends the report in HTML format to the specified address(es).  If
+// attach is true, CSV, JSON, and PDF renderings of the report are attached.
+func (r *Report) SendHTML(to []string, attach bool) error {
+	return sendEmail(to, func(to string) string { return r.RenderEmail(to, attach) })
 }
 
-// SendHTML sends the report in HTML format to the specified address(es).
-func (r *Report) SendHTML(to []string) error {
+// sendEmail sends an email to the specified address(es) via SMTP.  The render
+// function is given the To: line and returns the complete email.
+func sendEmail(to []string, render func(to string) string) error {
 	conf := config.Get().SMTP
 	var addrs []string
 	for i, t := range to {
@@ -54,7 +104,7 @@ func (r *Report) SendHTML(to []string) error {
 			return fmt.Errorf("address %q: %s", t, err)
 		}
 	}
-	msg := r.RenderEmail(strings.Join(to, ", "))
+	msg := render(strings.Join(to, ", "))
 	from, _ := mail.ParseAddress(conf.From)
 	host, _, _ := net.SplitHostPort(conf.Server)
 	auth := smtp.PlainAuth("", conf.Username, conf.Password, host)
@@ -0,0 +1,200 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rothskeller/wppsvr/store"
+)
+
+// A Summary contains the information that goes into a summary report covering
+// all of the practice sessions in a date range.
+type Summary struct {
+	Period          string
+	Sessions        []*SummarySession
+	MessageCount    int
+	AverageScore    int
+	UniqueCallSigns int
+	// NewCallSigns are the call signs that participated in this period but
+	// not in the previous one.  LostCallSigns are those that participated
+	// in the previous period but not in this one.
+	NewCallSigns  []string
+	LostCallSigns []string
+	// Buckets are the labels of the time buckets (sessions or months) in
+	// Jurisdictions.
+	Buckets       []string
+	Jurisdictions []*SummaryJurisdiction
+}
+
+// A SummarySession contains the information about a single session in a
+// Summary.
+type SummarySession struct {
+	Date            string
+	Name            string
+	MessageCount    int
+	UniqueCallSigns int
+	AverageScore    int
+}
+
+// A SummaryJurisdiction gives the number of unique call signs from a single
+// jurisdiction that participated in each time bucket of a Summary.
+type SummaryJurisdiction struct {
+	Name   string
+	Counts []int
+	Total  int
+}
+
+// GenerateSummary generates a summary report for the sessions ending in the
+// specified time range (inclusive start, exclusive end).
+func GenerateSummary(st Store, start, end time.Time) *Summary {
+	var (
+		s          Summary
+		byMonth    = end.Sub(start) > 45*24*time.Hour
+		bucketOf   = make(map[int]int)
+		jurisCalls = make(map[string][]map[string]bool)
+		jurisTotal = make(map[string]map[string]bool)
+		current    = make(map[string]bool)
+		previous   = make(map[string]bool)
+		scoreSum   int
+	)
+	s.Period = summaryPeriod(start, end)
+	sessions := summarySessions(st, start, end)
+	// Assign each session to a time bucket for the jurisdiction table.
+	for _, session := range sessions {
+		var label string
+		if byMonth {
+			label = session.End.Format("Jan")
+			if start.Year() != end.AddDate(0, 0, -1).Year() {
+				label = session.End.Format("Jan 06")
+			}
+		} else {
+			label = session.End.Format("Jan 2")
+		}
+		if len(s.Buckets) == 0 || s.Buckets[len(s.Buckets)-1] != label {
+			s.Buckets = append(s.Buckets, label)
+		}
+		bucketOf[session.ID] = len(s.Buckets) - 1
+	}
+	for _, session := range sessions {
+		var (
+			ss    = SummarySession{Date: session.End.Format("2006-01-02"), Name: session.Name}
+			calls = make(map[string]bool)
+		)
+		messages, _, _ := removeInvalidAndReplaced(removeDroppedMessages(st.GetSessionMessages(session.ID)))
+		for _, m := range messages {
+			ss.MessageCount++
+			ss.AverageScore += m.Score
+			scoreSum += m.Score
+			if m.FromCallSign == "" {
+				continue
+			}
+			calls[m.FromCallSign] = true
+			current[m.FromCallSign] = true
+			juris := m.Jurisdiction
+			if len(juris) != 3 {
+				juris = "???"
+			}
+			if jurisCalls[juris] == nil {
+				jurisCalls[juris] = make([]map[string]bool, len(s.Buckets))
+				jurisTotal[juris] = make(map[string]bool)
+			}
+			if jurisCalls[juris][bucketOf[session.ID]] == nil {
+				jurisCalls[juris][bucketOf[session.ID]] = make(map[string]bool)
+			}
+			jurisCalls[juris][bucketOf[session.ID]][m.FromCallSign] = true
+			jurisTotal[juris][m.FromCallSign] = true
+		}
+		if ss.MessageCount != 0 {
+			ss.AverageScore /= ss.MessageCount
+		}
+		ss.UniqueCallSigns = len(calls)
+		s.MessageCount += ss.MessageCount
+		s.Sessions = append(s.Sessions, &ss)
+	}
+	if s.MessageCount != 0 {
+		s.AverageScore = scoreSum / s.MessageCount
+	}
+	s.UniqueCallSigns = len(current)
+	// Compare the participants against those of the previous period.
+	pstart, pend := previousPeriod(start, end)
+	for _, session := range summarySessions(st, pstart, pend) {
+		messages, _, _ := removeInvalidAndReplaced(removeDroppedMessages(st.GetSessionMessages(session.ID)))
+		for _, m := range messages {
+			if m.FromCallSign != "" {
+				previous[m.FromCallSign] = true
+			}
+		}
+	}
+	for call := range current {
+		if !previous[call] {
+			s.NewCallSigns = append(s.NewCallSigns, call)
+		}
+	}
+	for call := range previous {
+		if !current[call] {
+			s.LostCallSigns = append(s.LostCallSigns, call)
+		}
+	}
+	sort.Strings(s.NewCallSigns)
+	sort.Strings(s.LostCallSigns)
+	// Build the jurisdiction table.
+	for juris, buckets := range jurisCalls {
+		sj := SummaryJurisdiction{Name: juris, Counts: make([]int, len(s.Buckets)), Total: len(jurisTotal[juris])}
+		for i, calls := range buckets {
+			sj.Counts[i] = len(calls)
+		}
+		s.Jurisdictions = append(s.Jurisdictions, &sj)
+	}
+	sort.Slice(s.Jurisdictions, func(i, j int) bool {
+		// "???" sorts last.
+		if (s.Jurisdictions[i].Name == "???") != (s.Jurisdictions[j].Name == "???") {
+			return s.Jurisdictions[j].Name == "???"
+		}
+		return s.Jurisdictions[i].Name < s.Jurisdictions[j].Name
+	})
+	return &s
+}
+
+// summarySessions returns the sessions ending in the specified time range that
+// should be included in a summary report.  Test sessions (those excluded from
+// weekly counts) and sessions imported from the old NCO scripts, for which we
+// have no messages, are omitted.
+func summarySessions(st Store, start, end time.Time) (sessions []*store.Session) {
+	for _, session := range st.GetSessions(start, end) {
+		if session.Flags&(store.ExcludeFromWeek|store.Imported) == 0 {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions
+}
+
+// previousPeriod returns the time range immediately preceding the specified
+// one.  If the range is a whole number of calendar months, so is the previous
+// period; otherwise, it has the same duration.
+func previousPeriod(start, end time.Time) (pstart, pend time.Time) {
+	if start.Day() == 1 && end.Day() == 1 && isMidnight(start) && isMidnight(end) {
+		months := (end.Year()-start.Year())*12 + int(end.Month()-start.Month())
+		return start.AddDate(0, -months, 0), start
+	}
+	return start.Add(-end.Sub(start)), start
+}
+
+// summaryPeriod returns a description of the time range covered by a summary
+// report.
+func summaryPeriod(start, end time.Time) string {
+	last := end.AddDate(0, 0, -1)
+	if start.Day() == 1 && end.Day() == 1 && isMidnight(start) && isMidnight(end) {
+		switch {
+		case start.AddDate(0, 1, 0).Equal(end):
+			return start.Format("January 2006")
+		case start.Month() == time.January && start.AddDate(1, 0, 0).Equal(end):
+			return start.Format("2006")
+		}
+	}
+	return fmt.Sprintf("%s through %s", start.Format("January 2, 2006"), last.Format("January 2, 2006"))
+}
+
+func isMidnight(t time.Time) bool {
+	return t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0
+}
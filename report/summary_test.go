@@ -0,0 +1,88 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+
+	"github.com/rothskeller/wppsvr/store"
+)
+
+func TestSummaryPeriod(t *testing.T) {
+	tests := []struct {
+		start, end time.Time
+		period     string
+		pstart     time.Time
+	}{
+		{
+			time.Date(2022, 4, 1, 0, 0, 0, 0, time.Local), time.Date(2022, 5, 1, 0, 0, 0, 0, time.Local),
+			"April 2022", time.Date(2022, 3, 1, 0, 0, 0, 0, time.Local),
+		},
+		{
+			time.Date(2022, 1, 1, 0, 0, 0, 0, time.Local), time.Date(2023, 1, 1, 0, 0, 0, 0, time.Local),
+			"2022", time.Date(2021, 1, 1, 0, 0, 0, 0, time.Local),
+		},
+		{
+			time.Date(2022, 1, 1, 0, 0, 0, 0, time.Local), time.Date(2022, 4, 1, 0, 0, 0, 0, time.Local),
+			"January 1, 2022 through March 31, 2022", time.Date(2021, 10, 1, 0, 0, 0, 0, time.Local),
+		},
+		{
+			time.Date(2022, 4, 3, 0, 0, 0, 0, time.Local), time.Date(2022, 4, 17, 0, 0, 0, 0, time.Local),
+			"April 3, 2022 through April 16, 2022", time.Date(2022, 3, 20, 0, 0, 0, 0, time.Local),
+		},
+	}
+	for _, tt := range tests {
+		if period := summaryPeriod(tt.start, tt.end); period != tt.period {
+			t.Errorf("summaryPeriod(%s, %s) = %q, want %q", tt.start, tt.end, period, tt.period)
+		}
+		if pstart, pend := previousPeriod(tt.start, tt.end); !pstart.Equal(tt.pstart) || !pend.Equal(tt.start) {
+			t.Errorf("previousPeriod(%s, %s) = %s, %s, want %s, %s", tt.start, tt.end, pstart, pend, tt.pstart, tt.start)
+		}
+	}
+}
+
+func TestGenerateSummary(t *testing.T) {
+	var st historyStore
+
+	// A session in the previous period, for the new and lost call signs.
+	st.add(&store.Session{ID: 1, Name: "SVECS Net", End: time.Date(2022, 3, 29, 20, 0, 0, 0, time.Local)},
+		&store.Message{FromCallSign: "KC6RSC", Jurisdiction: "SNY", Score: 100},
+		&store.Message{FromCallSign: "AA6BT", Jurisdiction: "SNY", Score: 100},
+	)
+	// The sessions in the period.  Invalid and replaced messages are not
+	// counted, nor are sessions excluded from weekly counts.
+	st.add(&store.Session{ID: 2, Name: "SVECS Net", End: time.Date(2022, 4, 5, 20, 0, 0, 0, time.Local)},
+		&store.Message{FromCallSign: "KC6RSC", Jurisdiction: "SNY", Score: 100},
+		&store.Message{FromCallSign: "K6SNY", Jurisdiction: "MTV", Score: 80},
+		&store.Message{FromCallSign: "W6XYZ", Jurisdiction: "SNY", Score: 0},
+	)
+	st.add(&store.Session{ID: 3, Name: "Test Check-Ins", End: time.Date(2022, 4, 8, 20, 0, 0, 0, time.Local), Flags: store.ExcludeFromWeek},
+		&store.Message{FromCallSign: "W6ABC", Jurisdiction: "SNY", Score: 100},
+	)
+	st.add(&store.Session{ID: 4, Name: "SVECS Net", End: time.Date(2022, 4, 12, 20, 0, 0, 0, time.Local)},
+		&store.Message{FromCallSign: "KC6RSC", Jurisdiction: "SNY", Score: 50},
+		&store.Message{FromCallSign: "KC6RSC", Jurisdiction: "SNY", Score: 100},
+	)
+	summary := GenerateSummary(&st, time.Date(2022, 4, 1, 0, 0, 0, 0, time.Local), time.Date(2022, 5, 1, 0, 0, 0, 0, time.Local))
+	expected := &Summary{
+		Period: "April 2022",
+		Sessions: []*SummarySession{
+			{Date: "2022-04-05", Name: "SVECS Net", MessageCount: 2, UniqueCallSigns: 2, AverageScore: 90},
+			{Date: "2022-04-12", Name: "SVECS Net", MessageCount: 1, UniqueCallSigns: 1, AverageScore: 100},
+		},
+		MessageCount:    3,
+		AverageScore:    93,
+		UniqueCallSigns: 2,
+		NewCallSigns:    []string{"K6SNY"},
+		LostCallSigns:   []string{"AA6BT"},
+		Buckets:         []string{"Apr 5", "Apr 12"},
+		Jurisdictions: []*SummaryJurisdiction{
+			{Name: "MTV", Counts: []int{1, 0}, Total: 1},
+			{Name: "SNY", Counts: []int{1, 1}, Total: 1},
+		},
+	}
+	for _, diff := range deep.Equal(summary, expected) {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,197 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"mime/quotedprintable"
+	"strconv"
+	"strings"
+
+	"github.com/rothskeller/wppsvr/config"
+	"github.com/rothskeller/wppsvr/english"
+)
+
+// RenderPlainText renders a summary report in plain text format.
+func (s *Summary) RenderPlainText() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "==== SCCo ARES/RACES Packet Practice Summary\n==== for %s\n\n", s.Period)
+	fmt.Fprintf(&sb, "%s, %s, %d%% average score\n", plural(len(s.Sessions), "session"),
+		plural(s.MessageCount, "counted message"), s.AverageScore)
+	fmt.Fprintf(&sb, "%d unique call signs (%d new, %d lost since previous period)\n\n",
+		s.UniqueCallSigns, len(s.NewCallSigns), len(s.LostCallSigns))
+	if len(s.Sessions) != 0 {
+		var col1, col2, col3, col4, col5 []string
+		for _, ss := range s.Sessions {
+			col1 = append(col1, ss.Date)
+			col2 = append(col2, ss.Name)
+			col3 = append(col3, strconv.Itoa(ss.MessageCount))
+			col4 = append(col4, strconv.Itoa(ss.UniqueCallSigns))
+			col5 = append(col5, strconv.Itoa(ss.AverageScore)+"%")
+		}
+		col1 = append([]string{"DATE"}, col1...)
+		col2 = append([]string{"SESSION"}, col2...)
+		col3 = append([]string{"MSGS"}, col3...)
+		col4 = append([]string{"CALLS"}, col4...)
+		col5 = append([]string{"SCORE"}, col5...)
+		rightAlign(col3)
+		rightAlign(col4)
+		rightAlign(col5)
+		lines := sideBySide(sideBySide(sideBySide(sideBySide(col1, col2, 2), col3, 2), col4, 2), col5, 2)
+		sb.WriteString("---- SESSIONS\n")
+		for _, line := range lines {
+			sb.WriteString(line)
+			sb.WriteByte('\n')
+		}
+		sb.WriteByte('\n')
+	}
+	if len(s.NewCallSigns) != 0 {
+		sb.WriteString("---- NEW PARTICIPANTS\n")
+		wr := english.NewWrapper(&sb)
+		wr.WriteString(strings.Join(s.NewCallSigns, " "))
+		wr.Close()
+		sb.WriteString("\n")
+	}
+	if len(s.LostCallSigns) != 0 {
+		sb.WriteString("---- PARTICIPANTS LOST SINCE PREVIOUS PERIOD\n")
+		wr := english.NewWrapper(&sb)
+		wr.WriteString(strings.Join(s.LostCallSigns, " "))
+		wr.Close()
+		sb.WriteString("\n")
+	}
+	if len(s.Jurisdictions) != 0 {
+		var cols = [][]string{{""}}
+		for _, sj := range s.Jurisdictions {
+			cols[0] = append(cols[0], sj.Name)
+		}
+		for i, bucket := range s.Buckets {
+			col := []string{bucket}
+			for _, sj := range s.Jurisdictions {
+				col = append(col, strconv.Itoa(sj.Counts[i]))
+			}
+			rightAlign(col)
+			cols = append(cols, col)
+		}
+		col := []string{"TOTAL"}
+		for _, sj := range s.Jurisdictions {
+			col = append(col, strconv.Itoa(sj.Total))
+		}
+		rightAlign(col)
+		cols = append(cols, col)
+		lines := cols[0]
+		for _, col := range cols[1:] {
+			lines = sideBySide(lines, col, 2)
+		}
+		sb.WriteString("---- PARTICIPATION BY JURISDICTION (unique call signs)\n")
+		for _, line := range lines {
+			sb.WriteString(line)
+			sb.WriteByte('\n')
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// RenderHTML renders a summary report as an HTML page.
+func (s *Summary) RenderHTML() string {
+	var sb strings.Builder
+
+	RenderHTMLProlog(&sb)
+	sb.WriteString(`<div id=report><div id=org>Santa Clara County ARES<sup>®</sup>/RACES</div><div id=title><a href=/>Weekly Packet Practice</a></div>`)
+	s.renderHTML(&sb)
+	sb.WriteString(`</div>`)
+	return sb.String()
+}
+
+// RenderEmail renders the summary report in a form suitable for emailing.  The
+// email is a multipart/alternative email with plain text and HTML variants.
+// The parameter is the To: line of the email.
+func (s *Summary) RenderEmail(to string) string {
+	var sb strings.Builder
+	var qw = quotedprintable.NewWriter(&sb)
+
+	fmt.Fprintf(&sb, "From: %s\r\nTo: %s\r\nSubject: SCCo Packet Practice Summary for %s\r\n", config.Get().SMTP.From, to, s.Period)
+	sb.WriteString("Content-Type: multipart/alternative; boundary=\"BOUNDARY\"\r\n\r\n\r\n--BOUNDARY\r\nContent-Type: text/plain\r\n\r\n")
+	sb.WriteString(strings.ReplaceAll(s.RenderPlainText(), "\n", "\r\n"))
+	sb.WriteString("\r\n--BOUNDARY\r\nContent-Type: text/html; charset=utf-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n")
+	io.WriteString(qw, `<!DOCTYPE html><html><head><meta charset="utf-8"></head><body><div style="max-width:800px;font-size:16px;line-height:1.25;padding:16px;font-family:Arial,Helvetica,sans-serif"><div style="color:#444;font-size:20px;font-weight:bold;text-align:center">Santa Clara County ARES<sup>®</sup>/RACES</div><div style="color:#D00;font-size:28px;font-weight:bold;text-align:center">Weekly Packet Practice</div>`)
+	s.renderHTML(qw)
+	io.WriteString(qw, "</div></body></html>\n")
+	sb.WriteString("\r\n--BOUNDARY--\r\n")
+	return sb.String()
+}
+
+// SendHTML sends the summary report in HTML format to the specified
+// address(es).
+func (s *Summary) SendHTML(to []string) error {
+	return sendEmail(to, s.RenderEmail)
+}
+
+// renderHTML renders the body of the summary report in HTML.  It uses inline
+// styles so that it can be used in emails as well as on the web.
+func (s *Summary) renderHTML(w io.Writer) {
+	const (
+		th  = `<th style="padding:2px 8px;text-align:%s;color:#444">%s</th>`
+		td  = `<td style="padding:2px 8px;text-align:%s">%s</td>`
+		sec = `<div style="font-size:20px;font-weight:bold;color:#444;margin-top:24px">%s</div>`
+	)
+	fmt.Fprintf(w, `<div style="font-size:28px;font-weight:bold;text-align:center">Summary — %s</div>`, html.EscapeString(s.Period))
+	fmt.Fprintf(w, `<div style="font-size:20px;font-weight:bold;text-align:center;margin-top:16px">%d Unique Call Signs</div>`, s.UniqueCallSigns)
+	fmt.Fprintf(w, `<div style="font-size:16px;font-weight:bold;color:#888;text-align:center">%d new, %d lost since previous period</div>`,
+		len(s.NewCallSigns), len(s.LostCallSigns))
+	fmt.Fprintf(w, `<div style="text-align:center;margin-top:8px">%s, %s, %d%% average score</div>`,
+		plural(len(s.Sessions), "session"), plural(s.MessageCount, "counted message"), s.AverageScore)
+	if len(s.Sessions) != 0 {
+		fmt.Fprintf(w, sec, "Sessions")
+		io.WriteString(w, `<table cellspacing="0" cellpadding="0"><tr>`)
+		fmt.Fprintf(w, th+th+th+th+th, "left", "Date", "left", "Session", "right", "Messages", "right", "Call Signs", "right", "Avg. Score")
+		io.WriteString(w, `</tr>`)
+		for _, ss := range s.Sessions {
+			io.WriteString(w, `<tr>`)
+			fmt.Fprintf(w, td+td+td+td+td, "left", ss.Date, "left", html.EscapeString(ss.Name),
+				"right", strconv.Itoa(ss.MessageCount), "right", strconv.Itoa(ss.UniqueCallSigns),
+				"right", strconv.Itoa(ss.AverageScore)+"%")
+			io.WriteString(w, `</tr>`)
+		}
+		io.WriteString(w, `</table>`)
+	}
+	if len(s.NewCallSigns) != 0 {
+		fmt.Fprintf(w, sec, "New Participants")
+		fmt.Fprintf(w, `<div>%s</div>`, strings.Join(s.NewCallSigns, " "))
+	}
+	if len(s.LostCallSigns) != 0 {
+		fmt.Fprintf(w, sec, "Participants Lost Since Previous Period")
+		fmt.Fprintf(w, `<div>%s</div>`, strings.Join(s.LostCallSigns, " "))
+	}
+	if len(s.Jurisdictions) != 0 {
+		fmt.Fprintf(w, sec, "Participation by Jurisdiction")
+		io.WriteString(w, `<div style="color:#888">Numbers of unique call signs</div><table cellspacing="0" cellpadding="0"><tr><th></th>`)
+		for _, bucket := range s.Buckets {
+			fmt.Fprintf(w, th, "right", html.EscapeString(bucket))
+		}
+		fmt.Fprintf(w, th, "right", "Total")
+		io.WriteString(w, `</tr>`)
+		for _, sj := range s.Jurisdictions {
+			fmt.Fprintf(w, `<tr><td style="padding:2px 8px;font-weight:bold">%s</td>`, sj.Name)
+			for _, count := range sj.Counts {
+				if count == 0 {
+					fmt.Fprintf(w, td, "right", "")
+				} else {
+					fmt.Fprintf(w, td, "right", strconv.Itoa(count))
+				}
+			}
+			fmt.Fprintf(w, td, "right", strconv.Itoa(sj.Total))
+			io.WriteString(w, `</tr>`)
+		}
+		io.WriteString(w, `</table>`)
+	}
+}
+
+// plural returns a count followed by a noun, pluralized if needed.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return strconv.Itoa(n) + " " + noun + "s"
+}
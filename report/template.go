@@ -0,0 +1,154 @@
+package report
+
+import (
+	_ "embed" // -
+	"html/template"
+	"log"
+	"os"
+	"strings"
+	ttemplate "text/template"
+
+	"github.com/rothskeller/wppsvr/english"
+)
+
+// The report renderers are driven by templates.  The defaults are embedded in
+// the program, but each can be overridden by a file of the same name in the
+// working directory.  Overrides are read each time a report is rendered, so
+// they can be changed without restarting the server.
+const (
+	textTemplateName  = "report-text.tmpl"
+	htmlTemplateName  = "report-html.tmpl"
+	emailTemplateName = "report-email.tmpl"
+)
+
+var (
+	//go:embed report-text.tmpl
+	defaultTextTemplate string
+	//go:embed report-html.tmpl
+	defaultHTMLTemplate string
+	//go:embed report-email.tmpl
+	defaultEmailTemplate string
+)
+
+// textFuncs are the functions available to plain text templates.
+var textFuncs = ttemplate.FuncMap{
+	"addRow":   addRow,
+	"conjoin":  english.Conjoin,
+	"join":     strings.Join,
+	"newTable": newTable,
+	"table":    table,
+	"upper":    strings.ToUpper,
+	"wrap":     wrap,
+}
+
+// htmlFuncs are the functions available to HTML templates.
+var htmlFuncs = template.FuncMap{
+	"callSignChart":  callSignChart,
+	"columns":        columns,
+	"conjoin":        english.Conjoin,
+	"esc":            esc,
+	"histogramChart": histogramChart,
+	"join":           strings.Join,
+	"nobreak":        nobreak,
+	"scoreChart":     scoreChart,
+	"sourceChart":    sourceChart,
+}
+
+// textTemplate returns the plain text template with the specified name.
+func textTemplate(name, def string) *ttemplate.Template {
+	if override, err := os.ReadFile(name); err == nil {
+		tmpl, err := ttemplate.New(name).Funcs(textFuncs).Parse(string(override))
+		if err == nil {
+			return tmpl
+		}
+		log.Printf("ERROR: %s, using default template", err)
+	}
+	return ttemplate.Must(ttemplate.New(name).Funcs(textFuncs).Parse(def))
+}
+
+// htmlTemplate returns the HTML template with the specified name.
+func htmlTemplate(name, def string) *template.Template {
+	if override, err := os.ReadFile(name); err == nil {
+		tmpl, err := template.New(name).Funcs(htmlFuncs).Parse(string(override))
+		if err == nil {
+			return tmpl
+		}
+		log.Printf("ERROR: %s, using default template", err)
+	}
+	return template.Must(template.New(name).Funcs(htmlFuncs).Parse(def))
+}
+
+// wrap returns the supplied text, word-wrapped at 78 columns.
+func wrap(text string) string {
+	var sb strings.Builder
+
+	wr := english.NewWrapper(&sb)
+	wr.WriteString(text)
+	wr.Close()
+	return sb.String()
+}
+
+// newTable returns an empty table, for use with addRow and table.
+func newTable() [][]string { return [][]string{} }
+
+// addRow appends a row to a table.
+func addRow(rows [][]string, cells ...string) [][]string {
+	return append(rows, cells)
+}
+
+// table formats a table with aligned columns.  The spec gives the alignment
+// of each column ('L' or 'R'), separated by the number of spaces between
+// columns (a single digit).  Each line of the result ends with a newline.
+func table(spec string, rows [][]string) string {
+	var lines []string
+
+	for col := 0; col*2 < len(spec); col++ {
+		var cells = make([]string, len(rows))
+		for i, row := range rows {
+			if col < len(row) {
+				cells[i] = row[col]
+			}
+		}
+		if spec[col*2] == 'R' {
+			rightAlign(cells)
+		}
+		if col == 0 {
+			lines = cells
+		} else {
+			lines = sideBySide(lines, cells, int(spec[col*2-1]-'0'))
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// columns splits a list of counts into columns of no more than max rows,
+// balancing the number of rows in each column.
+func columns(counts []*Count, max int) (cols [][]*Count) {
+	if len(counts) == 0 {
+		return nil
+	}
+	var ncols = (len(counts) + max - 1) / max
+	var rows = (len(counts) + ncols - 1) / ncols
+
+	for len(counts) > rows {
+		cols = append(cols, counts[:rows])
+		counts = counts[rows:]
+	}
+	return append(cols, counts)
+}
+
+// esc returns the supplied text, HTML-escaped.  Unlike the template's own
+// escaping, it escapes only the characters that are special to HTML, so that
+// (for example) plus signs in email addresses are left as is.
+func esc(text string) template.HTML {
+	return template.HTML(template.HTMLEscapeString(text))
+}
+
+// nobreak returns the supplied text, HTML-escaped, with spaces and hyphens
+// changed to non-breaking ones.
+func nobreak(text string) template.HTML {
+	return template.HTML(noBreakReplacer.Replace(template.HTMLEscapeString(text)))
+}
@@ -0,0 +1,57 @@
+package report
+
+import "github.com/rothskeller/wppsvr/store"
+
+// trendWeeks is the number of weeks of history shown in report trend charts.
+const trendWeeks = 12
+
+// generateTrends generates the history of the net (i.e., sessions with the
+// same call sign) over the last trendWeeks weeks, for the trend charts.  The
+// current session is the last point.  Trends are omitted if there is no
+// history.
+func generateTrends(r *Report, st Store, session *store.Session) {
+	for _, s := range st.GetSessions(session.End.AddDate(0, 0, -7*trendWeeks), session.End) {
+		if s.CallSign != session.CallSign || s.ID == session.ID || s.Flags&store.Imported != 0 {
+			continue
+		}
+		var (
+			tp    = TrendPoint{Date: s.End.Format("1/2")}
+			calls = make(map[string]bool)
+		)
+		messages, _, _ := removeInvalidAndReplaced(removeDroppedMessages(st.GetSessionMessages(s.ID)))
+		for _, m := range messages {
+			switch messageSource(m) {
+			case "Winlink":
+				tp.Winlink++
+			case "Email":
+				tp.Email++
+			default:
+				tp.BBS++
+			}
+			if m.FromCallSign != "" {
+				calls[m.FromCallSign] = true
+			}
+			tp.AverageScore += m.Score
+		}
+		if len(messages) != 0 {
+			tp.AverageScore /= len(messages)
+		}
+		tp.UniqueCallSigns = len(calls)
+		r.Trends = append(r.Trends, &tp)
+	}
+	if len(r.Trends) == 0 {
+		return
+	}
+	tp := TrendPoint{Date: session.End.Format("1/2"), UniqueCallSigns: r.UniqueCallSigns, AverageScore: r.AverageValidScore}
+	for _, source := range r.Sources {
+		switch source.Name {
+		case "Winlink":
+			tp.Winlink += source.Count
+		case "Email":
+			tp.Email += source.Count
+		default:
+			tp.BBS += source.Count
+		}
+	}
+	r.Trends = append(r.Trends, &tp)
+}
@@ -0,0 +1,57 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+
+	"github.com/rothskeller/wppsvr/store"
+)
+
+func TestGenerateTrends(t *testing.T) {
+	var st historyStore
+
+	// Sessions of other nets, and imported sessions, are not part of the
+	// trends.
+	st.add(&store.Session{ID: 1, CallSign: "PKTTUE", End: time.Date(2022, 3, 29, 20, 0, 0, 0, time.Local), Flags: store.Imported})
+	st.add(&store.Session{ID: 2, CallSign: "PKTTUE", End: time.Date(2022, 4, 5, 20, 0, 0, 0, time.Local)},
+		&store.Message{FromCallSign: "KC6RSC", FromBBS: "W1XSC", Score: 100},
+		&store.Message{FromCallSign: "K6SNY", FromAddress: "k6sny@winlink.org", Score: 80},
+	)
+	st.add(&store.Session{ID: 3, CallSign: "PKTMON", End: time.Date(2022, 4, 11, 20, 0, 0, 0, time.Local)},
+		&store.Message{FromCallSign: "KC6RSC", FromBBS: "W1XSC", Score: 100},
+	)
+	// Invalid and replaced messages are not counted.
+	st.add(&store.Session{ID: 4, CallSign: "PKTTUE", End: time.Date(2022, 4, 12, 20, 0, 0, 0, time.Local)},
+		&store.Message{FromCallSign: "KC6RSC", FromBBS: "W1XSC", Score: 50},
+		&store.Message{FromCallSign: "KC6RSC", FromBBS: "W1XSC", Score: 100},
+		&store.Message{FromCallSign: "AA6BT", FromAddress: "aa6bt@example.com", Score: 100},
+		&store.Message{FromCallSign: "W6XYZ", FromBBS: "W1XSC", Score: 0},
+	)
+	session := &store.Session{ID: 5, CallSign: "PKTTUE", End: time.Date(2022, 4, 19, 20, 0, 0, 0, time.Local)}
+	st.add(session,
+		&store.Message{FromCallSign: "KC6RSC", FromBBS: "W2XSC", Score: 100},
+		&store.Message{FromCallSign: "K6SNY", FromAddress: "K6SNY@Winlink.org", Score: 60},
+	)
+	var r Report
+	generateStatistics(&r, session, st.GetSessionMessages(session.ID))
+	generateTrends(&r, &st, session)
+	expected := []*TrendPoint{
+		{Date: "4/5", UniqueCallSigns: 2, AverageScore: 90, BBS: 1, Winlink: 1},
+		{Date: "4/12", UniqueCallSigns: 2, AverageScore: 100, BBS: 1, Email: 1},
+		{Date: "4/19", UniqueCallSigns: 2, AverageScore: 80, BBS: 1, Winlink: 1},
+	}
+	for _, diff := range deep.Equal(r.Trends, expected) {
+		t.Error(diff)
+	}
+	// A net with no history has no trends.
+	session = &store.Session{ID: 6, CallSign: "PKTWED", End: time.Date(2022, 4, 20, 20, 0, 0, 0, time.Local)}
+	st.add(session, &store.Message{FromCallSign: "KC6RSC", FromBBS: "W1XSC", Score: 100})
+	r = Report{}
+	generateStatistics(&r, session, st.GetSessionMessages(session.ID))
+	generateTrends(&r, &st, session)
+	if r.Trends != nil {
+		t.Errorf("trends generated with no history: %d points", len(r.Trends))
+	}
+}
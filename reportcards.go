@@ -0,0 +1,97 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/rothskeller/packet/jnos"
+	"github.com/rothskeller/wppsvr/report"
+	"github.com/rothskeller/wppsvr/retrieve"
+	"github.com/rothskeller/wppsvr/store"
+)
+
+// sendReportCards sends the monthly and quarterly report cards to the
+// participants who have asked for them.  They cover the previous month and the
+// previous calendar quarter, respectively.
+func sendReportCards(st *store.Store) {
+	var (
+		now         = time.Now()
+		thisMonth   = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+		thisQuarter = thisMonth.AddDate(0, -(int(now.Month()-1) % 3), 0)
+	)
+	sendReportCardsFor(st, "monthly", thisMonth.AddDate(0, -1, 0), thisMonth)
+	sendReportCardsFor(st, "quarterly", thisQuarter.AddDate(0, -3, 0), thisQuarter)
+}
+
+// sendReportCardsFor sends the report cards of the specified frequency for the
+// specified period, unless they have already been sent.  As with summary
+// reports, report cards more than a week overdue are not sent.  The period is
+// marked as sent only when all of its report cards have been sent; until then,
+// each call retries the ones that failed.
+func sendReportCardsFor(st *store.Store, frequency string, start, end time.Time) {
+	var (
+		kind     = "reportcard-" + frequency
+		period   = start.Format("2006-01-02")
+		sessions []*store.Session
+		conn     *jnos.Conn
+	)
+	if time.Since(end) > 7*24*time.Hour || st.PeriodicSent(kind, period) {
+		return
+	}
+	// Don't send the report cards until all of the sessions in the period
+	// are closed.
+	for _, session := range st.GetSessions(start, end) {
+		if session.Flags&store.Running != 0 {
+			return
+		}
+		if session.Flags&(store.ExcludeFromWeek|store.Imported) == 0 {
+			sessions = append(sessions, session)
+		}
+	}
+	// If there were no practice sessions in the period, there's nothing
+	// to report.
+	if participants := st.GetReportCardParticipants(frequency); len(participants) != 0 && len(sessions) != 0 {
+		var (
+			cards   []*report.ReportCard
+			needBBS bool
+			failed  int
+		)
+		// Each report card that is sent successfully is recorded, so
+		// that if some fail, only those are tried again later.
+		for _, rc := range report.GenerateReportCards(st, start, end, participants) {
+			if st.PeriodicSent(kind+":"+rc.CallSign, period) {
+				continue
+			}
+			if rc.Email == "" && rc.Address == "" {
+				// This one can never be sent; don't try again.
+				log.Printf("ERROR: sending %s report card to %s: no known address", frequency, rc.CallSign)
+				st.MarkPeriodicSent(kind+":"+rc.CallSign, period)
+				continue
+			}
+			cards = append(cards, rc)
+			needBBS = needBBS || rc.Email == ""
+		}
+		// Report cards sent by BBS come from the mailbox of the last
+		// session in the period.
+		var last = sessions[len(sessions)-1]
+		if needBBS {
+			if conn = retrieve.ConnectToBBS(last.ToBBSes[0], last.CallSign); conn != nil {
+				defer conn.Close()
+			}
+		}
+		for _, rc := range cards {
+			if err := rc.Send(st, conn, last.Prefix); err != nil {
+				log.Printf("ERROR: sending %s report card to %s: %s", frequency, rc.CallSign, err)
+				failed++
+				continue
+			}
+			st.MarkPeriodicSent(kind+":"+rc.CallSign, period)
+		}
+		if failed != 0 {
+			log.Printf("ERROR: %d of %d %s report cards for the period starting %s were not sent; will try again later.", failed, len(cards), frequency, period)
+			return
+		}
+		log.Printf("Sent %s report cards for the period starting %s.", frequency, period)
+	}
+	st.MarkPeriodicSent(kind, period)
+}
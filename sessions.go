@@ -21,8 +21,9 @@ func closeSessions(st *store.Store) {
 			log.Printf("Closed session for %s ending %s.", session.Name, session.End.Format("2006-01-02 15:04"))
 			if len(session.ReportToText) != 0 || len(session.ReportToHTML) != 0 || st.SessionHasMessages(session.ID) {
 				var conn = retrieve.ConnectToBBS(session.ToBBSes[0], session.CallSign)
-				report.Send(st, conn, session)
+				rpt := report.Send(st, conn, session)
 				conn.Close()
+				st.PublishReport(session.ID, rpt.Synopsis())
 			}
 		}
 	}
@@ -44,5 +45,8 @@ func openSessions(st *store.Store) {
 		session.Flags |= store.Running
 		st.UpdateSession(session)
 		log.Printf("Opened session for %s ending %s.", session.Name, session.End.Format("2006-01-02 15:04"))
+		if session.ExerciseMsg != nil && len(session.ExerciseTo) == 0 {
+			log.Printf("ERROR: %s is a two-way exercise with no addresses to send the exercise message to", session.Name)
+		}
 	}
 }
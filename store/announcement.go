@@ -0,0 +1,49 @@
+package store
+
+import (
+	"time"
+
+	"github.com/rothskeller/wppsvr/db"
+)
+
+// An Announcement records the posting of a session's announcement to one of
+// its announcement addresses.
+type Announcement struct {
+	Session int
+	To      string
+	Sent    time.Time
+	// Error is the reason the announcement could not be sent, or empty if
+	// it was sent successfully.
+	Error string
+}
+
+// GetAnnouncements returns the announcements posted for the specified session,
+// in the order they were sent.
+func (st *Store) GetAnnouncements(sessionID int) (list []*Announcement) {
+	db.SQL(st.conn, "SELECT sendto, sent, error FROM announcement WHERE session=? ORDER BY sent, sendto", func(st *db.St) {
+		st.BindInt(sessionID)
+		for st.Step() {
+			var a = Announcement{Session: sessionID}
+
+			a.To = st.ColumnText()
+			a.Sent = st.ColumnTime(sendTimeFormat)
+			a.Error = st.ColumnText()
+			list = append(list, &a)
+		}
+	})
+	return list
+}
+
+// SaveAnnouncement records the posting of an announcement.
+func (st *Store) SaveAnnouncement(a *Announcement) {
+	db.Transaction(st.conn, true, func() error {
+		db.SQL(st.conn, "INSERT OR REPLACE INTO announcement (session, sendto, sent, error) VALUES (?,?,?,?)", func(st *db.St) {
+			st.BindInt(a.Session)
+			st.BindText(a.To)
+			st.BindTime(a.Sent, sendTimeFormat)
+			st.BindText(a.Error)
+			st.Step()
+		})
+		return nil
+	})
+}
@@ -0,0 +1,74 @@
+package store
+
+import (
+	"time"
+
+	"github.com/rothskeller/wppsvr/db"
+)
+
+// An ExerciseMessage records the sending of a two-way exercise session's
+// exercise message to one of its participants.
+type ExerciseMessage struct {
+	Session  int    `yaml:"session"`
+	CallSign string `yaml:"callSign"`
+	// MessageID is the message number of the exercise message sent to the
+	// participant.  Their reply must reference it.
+	MessageID string    `yaml:"messageID"`
+	To        string    `yaml:"to"`
+	Sent      time.Time `yaml:"sent"`
+	// Error is the reason the exercise message could not be sent, or empty
+	// if it was sent successfully.
+	Error string `yaml:"error"`
+}
+
+// GetExerciseMessages returns the exercise messages sent for the specified
+// session, in call sign order.
+func (st *Store) GetExerciseMessages(sessionID int) (list []*ExerciseMessage) {
+	db.SQL(st.conn, "SELECT callsign, msgid, sendto, sent, error FROM exercise WHERE session=? ORDER BY callsign", func(st *db.St) {
+		st.BindInt(sessionID)
+		for st.Step() {
+			var em = ExerciseMessage{Session: sessionID}
+
+			em.CallSign = st.ColumnText()
+			em.MessageID = st.ColumnText()
+			em.To = st.ColumnText()
+			em.Sent = st.ColumnTime(sendTimeFormat)
+			em.Error = st.ColumnText()
+			list = append(list, &em)
+		}
+	})
+	return list
+}
+
+// GetExerciseMessage returns the exercise message sent for the specified
+// session with the specified message number, or nil if there is none.
+func (st *Store) GetExerciseMessage(sessionID int, msgid string) (em *ExerciseMessage) {
+	db.SQL(st.conn, "SELECT callsign, sendto, sent, error FROM exercise WHERE session=? AND msgid=?", func(st *db.St) {
+		st.BindInt(sessionID)
+		st.BindText(msgid)
+		if st.Step() {
+			em = &ExerciseMessage{Session: sessionID, MessageID: msgid}
+			em.CallSign = st.ColumnText()
+			em.To = st.ColumnText()
+			em.Sent = st.ColumnTime(sendTimeFormat)
+			em.Error = st.ColumnText()
+		}
+	})
+	return em
+}
+
+// SaveExerciseMessage records the sending of an exercise message.
+func (st *Store) SaveExerciseMessage(em *ExerciseMessage) {
+	db.Transaction(st.conn, true, func() error {
+		db.SQL(st.conn, "INSERT OR REPLACE INTO exercise (session, callsign, msgid, sendto, sent, error) VALUES (?,?,?,?,?,?)", func(st *db.St) {
+			st.BindInt(em.Session)
+			st.BindText(em.CallSign)
+			st.BindText(em.MessageID)
+			st.BindText(em.To)
+			st.BindTime(em.Sent, sendTimeFormat)
+			st.BindText(em.Error)
+			st.Step()
+		})
+		return nil
+	})
+}
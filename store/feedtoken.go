@@ -0,0 +1,45 @@
+package store
+
+import (
+	"github.com/rothskeller/wppsvr/db"
+)
+
+// GetFeedToken returns the token that identifies the participant with the
+// specified call sign in their personal feed URLs, or an empty string if they
+// don't have one yet.  Feeds are fetched by calendar programs and feed readers,
+// which can't log in, so a feed token takes the place of a login; it is secret
+// like a password, and gives access only to the participant's feeds.
+func (st *Store) GetFeedToken(callsign string) (token string) {
+	db.SQL(st.conn, "SELECT token FROM feedtoken WHERE callsign=?", func(st *db.St) {
+		st.BindText(callsign)
+		if st.Step() {
+			token = st.ColumnText()
+		}
+	})
+	return token
+}
+
+// GetFeedCallSign returns the call sign of the participant identified by the
+// specified personal feed token, or an empty string if the token is not valid.
+func (st *Store) GetFeedCallSign(token string) (callsign string) {
+	db.SQL(st.conn, "SELECT callsign FROM feedtoken WHERE token=?", func(st *db.St) {
+		st.BindText(token)
+		if st.Step() {
+			callsign = st.ColumnText()
+		}
+	})
+	return callsign
+}
+
+// SaveFeedToken saves the personal feed token for the participant with the
+// specified call sign, replacing any previous one.
+func (st *Store) SaveFeedToken(callsign, token string) {
+	db.Transaction(st.conn, true, func() error {
+		db.SQL(st.conn, "INSERT OR REPLACE INTO feedtoken (callsign, token) VALUES (?,?)", func(st *db.St) {
+			st.BindText(callsign)
+			st.BindText(token)
+			st.Step()
+		})
+		return nil
+	})
+}
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"strings"
 	"time"
 
 	"github.com/rothskeller/wppsvr/db"
@@ -23,6 +24,7 @@ type Message struct {
 	MessageType  string    `yaml:"messageType"`
 	Score        int       `yaml:"score"`
 	Summary      string    `yaml:"summary"`
+	Problems     []string  `yaml:"problems"`
 	Analysis     string    `yaml:"analysis"`
 }
 
@@ -41,7 +43,7 @@ func (st *Store) SessionHasMessages(sessionID int) (found bool) {
 // GetMessage returns the message with the specified local ID, or nil if there
 // is none.
 func (st *Store) GetMessage(localID string) (m *Message) {
-	db.SQL(st.conn, "SELECT session, hash, deliverytime, message, fromaddress, fromcallsign, frombbs, tobbs, jurisdiction, messagetype, score, summary, analysis FROM message WHERE id=?", func(st *db.St) {
+	db.SQL(st.conn, "SELECT session, hash, deliverytime, message, fromaddress, fromcallsign, frombbs, tobbs, jurisdiction, messagetype, score, summary, problems, analysis FROM message WHERE id=?", func(st *db.St) {
 		st.BindText(localID)
 		if st.Step() {
 			m = new(Message)
@@ -58,6 +60,7 @@ func (st *Store) GetMessage(localID string) (m *Message) {
 			m.MessageType = st.ColumnText()
 			m.Score = st.ColumnInt()
 			m.Summary = st.ColumnText()
+			m.Problems = split(st.ColumnText())
 			m.Analysis = st.ColumnText()
 		}
 	})
@@ -67,7 +70,7 @@ func (st *Store) GetMessage(localID string) (m *Message) {
 // GetMessageByHash returns the message with the specified hash, or nil if there
 // is none.
 func (st *Store) GetMessageByHash(hash string) (m *Message) {
-	db.SQL(st.conn, "SELECT id, session, deliverytime, message, fromaddress, fromcallsign, frombbs, tobbs, jurisdiction, messagetype, score, summary, analysis FROM message WHERE hash=?", func(st *db.St) {
+	db.SQL(st.conn, "SELECT id, session, deliverytime, message, fromaddress, fromcallsign, frombbs, tobbs, jurisdiction, messagetype, score, summary, problems, analysis FROM message WHERE hash=?", func(st *db.St) {
 		st.BindText(hash)
 		if st.Step() {
 			m = new(Message)
@@ -84,6 +87,7 @@ func (st *Store) GetMessageByHash(hash string) (m *Message) {
 			m.MessageType = st.ColumnText()
 			m.Score = st.ColumnInt()
 			m.Summary = st.ColumnText()
+			m.Problems = split(st.ColumnText())
 			m.Analysis = st.ColumnText()
 		}
 	})
@@ -93,7 +97,7 @@ func (st *Store) GetMessageByHash(hash string) (m *Message) {
 // GetSessionMessages returns the set of messages received for the session, in
 // the order they were delivered to the BBS at which they were received.
 func (st *Store) GetSessionMessages(sessionID int) (messages []*Message) {
-	db.SQL(st.conn, "SELECT id, hash, deliverytime, message, fromaddress, fromcallsign, frombbs, tobbs, jurisdiction, messagetype, score, summary, analysis FROM message WHERE session=? ORDER BY deliverytime", func(st *db.St) {
+	db.SQL(st.conn, "SELECT id, hash, deliverytime, message, fromaddress, fromcallsign, frombbs, tobbs, jurisdiction, messagetype, score, summary, problems, analysis FROM message WHERE session=? ORDER BY deliverytime", func(st *db.St) {
 		st.BindInt(sessionID)
 		for st.Step() {
 			var m Message
@@ -111,6 +115,7 @@ func (st *Store) GetSessionMessages(sessionID int) (messages []*Message) {
 			m.MessageType = st.ColumnText()
 			m.Score = st.ColumnInt()
 			m.Summary = st.ColumnText()
+			m.Problems = split(st.ColumnText())
 			m.Analysis = st.ColumnText()
 			messages = append(messages, &m)
 		}
@@ -118,6 +123,30 @@ func (st *Store) GetSessionMessages(sessionID int) (messages []*Message) {
 	return messages
 }
 
+// GetCallSignScores returns the from addresses and scores of the messages
+// from the specified call sign, received for sessions ending during the
+// specified time range (inclusive start, exclusive end).  The returned map is
+// keyed by session ID; each session's messages are in the order they were
+// delivered.  Only the Session, FromAddress, FromCallSign, and Score fields of
+// the messages are filled in.
+func (st *Store) GetCallSignScores(callsign string, start, end time.Time) (messages map[int][]*Message) {
+	messages = make(map[int][]*Message)
+	db.SQL(st.conn, "SELECT m.session, m.fromaddress, m.score FROM message m, session s WHERE m.session=s.id AND m.fromcallsign=? AND s.end>=? AND s.end<? ORDER BY m.deliverytime", func(st *db.St) {
+		st.BindText(callsign)
+		st.BindTime(start, startEndFormat)
+		st.BindTime(end, startEndFormat)
+		for st.Step() {
+			var m = Message{FromCallSign: callsign}
+
+			m.Session = st.ColumnInt()
+			m.FromAddress = st.ColumnText()
+			m.Score = st.ColumnInt()
+			messages[m.Session] = append(messages[m.Session], &m)
+		}
+	})
+	return messages
+}
+
 // HasMessageHash looks to see whether the database already contains a message
 // with the specified hash.  If so, it returns the ID of that message; if not,
 // it returns an empty string.
@@ -134,7 +163,7 @@ func (st *Store) HasMessageHash(hash string) (id string) {
 // SaveMessage saves a message to the database.
 func (st *Store) SaveMessage(m *Message) {
 	db.Transaction(st.conn, true, func() error {
-		db.SQL(st.conn, "INSERT OR REPLACE INTO message (id, hash, deliverytime, message, session, fromaddress, fromcallsign, frombbs, tobbs, jurisdiction, messagetype, score, summary, analysis) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?)", func(st *db.St) {
+		db.SQL(st.conn, "INSERT OR REPLACE INTO message (id, hash, deliverytime, message, session, fromaddress, fromcallsign, frombbs, tobbs, jurisdiction, messagetype, score, summary, problems, analysis) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)", func(st *db.St) {
 			st.BindText(m.LocalID)
 			st.BindText(m.Hash)
 			st.BindTime(m.DeliveryTime, deliveryTimeFormat)
@@ -148,9 +177,11 @@ func (st *Store) SaveMessage(m *Message) {
 			st.BindText(m.MessageType)
 			st.BindInt(m.Score)
 			st.BindText(m.Summary)
+			st.BindText(strings.Join(m.Problems, ";"))
 			st.BindText(m.Analysis)
 			st.Step()
 		})
+		bumpSessionVersion(st.conn, m.Session)
 		return nil
 	})
 }
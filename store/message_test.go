@@ -0,0 +1,46 @@
+package store_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rothskeller/wppsvr/store"
+)
+
+func TestGetCallSignScores(t *testing.T) {
+	var (
+		st       = openTestStore(t)
+		sessions []*store.Session
+	)
+	for i, end := range []time.Time{
+		time.Date(2022, 4, 12, 20, 0, 0, 0, time.Local),
+		time.Date(2022, 4, 19, 20, 0, 0, 0, time.Local),
+		time.Date(2022, 4, 26, 20, 0, 0, 0, time.Local),
+	} {
+		session := &store.Session{CallSign: "PKTTUE", Name: "SVECS Net", Prefix: "TUE", Start: end.AddDate(0, 0, -6), End: end}
+		st.CreateSession(session)
+		sessions = append(sessions, session)
+		st.SaveMessage(&store.Message{
+			LocalID: fmt.Sprintf("TUE-10%dP", i), Hash: fmt.Sprintf("kc6rsc%d", i), Session: session.ID,
+			FromAddress: "kc6rsc@w1xsc.ampr.org", FromCallSign: "KC6RSC", MessageType: "plain", Score: 100 - i,
+			DeliveryTime: end.Add(-time.Hour),
+		})
+	}
+	// Messages from other call signs aren't included.
+	st.SaveMessage(&store.Message{
+		LocalID: "TUE-200P", Hash: "aa6bt", Session: sessions[1].ID,
+		FromAddress: "aa6bt@w1xsc.ampr.org", FromCallSign: "AA6BT", MessageType: "plain", Score: 100,
+		DeliveryTime: sessions[1].End.Add(-time.Hour),
+	})
+	// Only sessions ending in the time range are included.
+	scores := st.GetCallSignScores("KC6RSC", sessions[0].End, sessions[2].End)
+	if len(scores) != 2 {
+		t.Fatalf("scores for %d sessions, want 2", len(scores))
+	}
+	for i, session := range sessions[:2] {
+		if list := scores[session.ID]; len(list) != 1 || list[0].FromAddress != "kc6rsc@w1xsc.ampr.org" || list[0].Score != 100-i {
+			t.Errorf("incorrect scores for session %d: %v", i, list)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package store
+
+import (
+	"time"
+
+	"github.com/rothskeller/wppsvr/db"
+)
+
+// An OutboxMessage is a message queued to be sent from the mailbox of a
+// session (other than a response to a received message, which is sent right
+// away).
+type OutboxMessage struct {
+	ID      int
+	Session int
+	To      string
+	Subject string
+	Body    string
+	Queued  time.Time
+	// Sent is the time the message was sent, or zero if it hasn't been
+	// sent yet.
+	Sent time.Time
+}
+
+// QueueMessage adds a message to the outbox.  It sets the ID and queue time of
+// the message.
+func (st *Store) QueueMessage(om *OutboxMessage) {
+	om.Queued = time.Now()
+	db.Transaction(st.conn, true, func() error {
+		db.SQL(st.conn, "INSERT INTO outbox (session, sendto, subject, body, queued, sent) VALUES (?,?,?,?,?,NULL)", func(st *db.St) {
+			st.BindInt(om.Session)
+			st.BindText(om.To)
+			st.BindText(om.Subject)
+			st.BindText(om.Body)
+			st.BindTime(om.Queued, sendTimeFormat)
+			st.Step()
+		})
+		om.ID = int(st.conn.LastInsertRowID())
+		return nil
+	})
+}
+
+// GetUnsentMessages returns the messages in the outbox that were queued since
+// the specified time and have not yet been sent, in session and queue order.
+func (st *Store) GetUnsentMessages(since time.Time) (list []*OutboxMessage) {
+	db.SQL(st.conn, "SELECT id, session, sendto, subject, body, queued FROM outbox WHERE sent IS NULL AND queued>=? ORDER BY session, id", func(st *db.St) {
+		st.BindTime(since, sendTimeFormat)
+		for st.Step() {
+			var om OutboxMessage
+
+			om.ID = st.ColumnInt()
+			om.Session = st.ColumnInt()
+			om.To = st.ColumnText()
+			om.Subject = st.ColumnText()
+			om.Body = st.ColumnText()
+			om.Queued = st.ColumnTime(sendTimeFormat)
+			list = append(list, &om)
+		}
+	})
+	return list
+}
+
+// MarkMessageSent records that a message in the outbox has been sent.
+func (st *Store) MarkMessageSent(om *OutboxMessage) {
+	om.Sent = time.Now()
+	db.Transaction(st.conn, true, func() error {
+		db.SQL(st.conn, "UPDATE outbox SET sent=? WHERE id=?", func(st *db.St) {
+			st.BindTime(om.Sent, sendTimeFormat)
+			st.BindInt(om.ID)
+			st.Step()
+		})
+		return nil
+	})
+}
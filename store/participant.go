@@ -0,0 +1,105 @@
+package store
+
+import (
+	"github.com/rothskeller/wppsvr/db"
+)
+
+// A Participant contains the preferences of a participant, set through their
+// profile page.
+type Participant struct {
+	CallSign string
+	// Email is the email address to which the participant's report cards
+	// should be sent.  If it is empty, they are sent to the participant's
+	// BBS mailbox.
+	Email string
+	// PendingEmail is an email address the participant has asked to have
+	// their report cards sent to, but hasn't yet confirmed by following the
+	// link sent to it.  EmailToken is the secret in that link.  Once the
+	// address is confirmed, it replaces Email.
+	PendingEmail string
+	EmailToken   string
+	// ReportCard is the frequency with which the participant wants to
+	// receive report cards:  "monthly", "quarterly", or empty for never.
+	ReportCard string
+	// NoReminders is true if the participant doesn't want reminders to
+	// check in to sessions they haven't checked in to yet.
+	NoReminders bool
+}
+
+// GetParticipant returns the preferences of the participant with the specified
+// call sign.  If there are none stored, it returns default preferences.
+func (st *Store) GetParticipant(callsign string) (p *Participant) {
+	p = &Participant{CallSign: callsign}
+	db.SQL(st.conn, "SELECT email, pendingemail, emailtoken, reportcard, noreminders FROM participant WHERE callsign=?", func(st *db.St) {
+		st.BindText(callsign)
+		if st.Step() {
+			p.Email = st.ColumnText()
+			p.PendingEmail = st.ColumnText()
+			p.EmailToken = st.ColumnText()
+			p.ReportCard = st.ColumnText()
+			p.NoReminders = st.ColumnBool()
+		}
+	})
+	return p
+}
+
+// GetParticipantByEmailToken returns the preferences of the participant with a
+// pending email address confirmation having the specified token, or nil if
+// there is none.
+func (st *Store) GetParticipantByEmailToken(token string) (p *Participant) {
+	if token == "" {
+		return nil
+	}
+	db.SQL(st.conn, "SELECT callsign, email, pendingemail, reportcard, noreminders FROM participant WHERE emailtoken=?", func(st *db.St) {
+		st.BindText(token)
+		if st.Step() {
+			p = &Participant{EmailToken: token}
+			p.CallSign = st.ColumnText()
+			p.Email = st.ColumnText()
+			p.PendingEmail = st.ColumnText()
+			p.ReportCard = st.ColumnText()
+			p.NoReminders = st.ColumnBool()
+		}
+	})
+	return p
+}
+
+// GetReportCardParticipants returns the preferences of all participants who
+// want report cards with the specified frequency.
+func (st *Store) GetReportCardParticipants(frequency string) (participants []*Participant) {
+	db.SQL(st.conn, "SELECT callsign, email FROM participant WHERE reportcard=? ORDER BY callsign", func(st *db.St) {
+		st.BindText(frequency)
+		for st.Step() {
+			var p = Participant{ReportCard: frequency}
+
+			p.CallSign = st.ColumnText()
+			p.Email = st.ColumnText()
+			participants = append(participants, &p)
+		}
+	})
+	return participants
+}
+
+// SaveParticipant saves the preferences of a participant.  Participants with
+// default preferences are removed from the database.
+func (st *Store) SaveParticipant(p *Participant) {
+	db.Transaction(st.conn, true, func() error {
+		if p.Email == "" && p.PendingEmail == "" && p.ReportCard == "" && !p.NoReminders {
+			db.SQL(st.conn, "DELETE FROM participant WHERE callsign=?", func(st *db.St) {
+				st.BindText(p.CallSign)
+				st.Step()
+			})
+			return nil
+		}
+		db.SQL(st.conn, "INSERT OR REPLACE INTO participant (callsign, email, pendingemail, emailtoken, reportcard, noreminders) VALUES (?,?,?,?,?,?)", func(st *db.St) {
+			st.BindText(p.CallSign)
+			st.BindText(p.Email)
+			st.BindText(p.PendingEmail)
+			st.BindText(p.EmailToken)
+			st.BindText(p.ReportCard)
+			st.BindBool(p.NoReminders)
+			st.Step()
+		})
+		return nil
+	})
+}
@@ -0,0 +1,29 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/rothskeller/wppsvr/store"
+)
+
+func TestParticipantEmailToken(t *testing.T) {
+	var st = openTestStore(t)
+
+	if p := st.GetParticipantByEmailToken(""); p != nil {
+		t.Errorf("found participant %s with empty token", p.CallSign)
+	}
+	st.SaveParticipant(&store.Participant{CallSign: "KC6RSC", PendingEmail: "kc6rsc@example.com", EmailToken: "secret"})
+	p := st.GetParticipantByEmailToken("secret")
+	if p == nil || p.CallSign != "KC6RSC" || p.PendingEmail != "kc6rsc@example.com" || p.Email != "" {
+		t.Fatalf("GetParticipantByEmailToken = %+v", p)
+	}
+	// Once confirmed, the address is used and the token no longer works.
+	p.Email, p.PendingEmail, p.EmailToken = p.PendingEmail, "", ""
+	st.SaveParticipant(p)
+	if p := st.GetParticipantByEmailToken("secret"); p != nil {
+		t.Error("token still valid after confirmation")
+	}
+	if p := st.GetParticipant("KC6RSC"); p.Email != "kc6rsc@example.com" || p.PendingEmail != "" {
+		t.Errorf("GetParticipant after confirmation = %+v", p)
+	}
+}
@@ -0,0 +1,32 @@
+package store
+
+import (
+	"time"
+
+	"github.com/rothskeller/wppsvr/db"
+)
+
+// PeriodicSent returns whether the periodic mailing of the specified kind has
+// already been sent for the specified period.
+func (st *Store) PeriodicSent(kind, period string) (sent bool) {
+	db.SQL(st.conn, "SELECT 1 FROM periodic WHERE kind=? AND period=?", func(st *db.St) {
+		st.BindText(kind)
+		st.BindText(period)
+		sent = st.Step()
+	})
+	return sent
+}
+
+// MarkPeriodicSent records that the periodic mailing of the specified kind has
+// been sent for the specified period.
+func (st *Store) MarkPeriodicSent(kind, period string) {
+	db.Transaction(st.conn, true, func() error {
+		db.SQL(st.conn, "INSERT OR REPLACE INTO periodic (kind, period, sent) VALUES (?,?,?)", func(st *db.St) {
+			st.BindText(kind)
+			st.BindText(period)
+			st.BindTime(time.Now(), sendTimeFormat)
+			st.Step()
+		})
+		return nil
+	})
+}
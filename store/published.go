@@ -0,0 +1,59 @@
+package store
+
+import (
+	"time"
+
+	"github.com/rothskeller/wppsvr/db"
+)
+
+// A PublishedReport describes the final report of a session, as published in
+// the report feed.
+type PublishedReport struct {
+	SessionID   int
+	SessionName string
+	SessionEnd  time.Time
+	Published   time.Time
+	// Synopsis is a one-line summary of the report statistics.
+	Synopsis string
+	// Report is the plain text rendering of the full report.
+	Report string
+}
+
+const publishedFormat = "2006-01-02 15:04:05-07:00"
+
+// PublishReport records that the final report of the specified session was
+// published at the current time, with the specified synopsis.  The report
+// itself is the one saved in the session.
+func (st *Store) PublishReport(sessionID int, synopsis string) {
+	db.Transaction(st.conn, true, func() error {
+		db.SQL(st.conn, "INSERT OR REPLACE INTO published (session, published, synopsis) VALUES (?,?,?)", func(st *db.St) {
+			st.BindInt(sessionID)
+			st.BindTime(time.Now(), publishedFormat)
+			st.BindText(synopsis)
+			st.Step()
+		})
+		return nil
+	})
+}
+
+// GetPublishedReports returns the most recently published reports, newest
+// first, up to the specified limit.  If name is not empty, only reports for
+// sessions with that name are returned.
+func (st *Store) GetPublishedReports(name string, limit int) (list []*PublishedReport) {
+	db.SQL(st.conn, "SELECT s.id, s.name, s.end, p.published, p.synopsis, s.report FROM published p, session s WHERE p.session=s.id AND (?1='' OR s.name=?1) ORDER BY p.published DESC LIMIT ?2", func(st *db.St) {
+		st.BindText(name)
+		st.BindInt(limit)
+		for st.Step() {
+			var pr PublishedReport
+
+			pr.SessionID = st.ColumnInt()
+			pr.SessionName = st.ColumnText()
+			pr.SessionEnd = st.ColumnTime(startEndFormat)
+			pr.Published = st.ColumnTime(publishedFormat)
+			pr.Synopsis = st.ColumnText()
+			pr.Report = st.ColumnText()
+			list = append(list, &pr)
+		}
+	})
+	return list
+}
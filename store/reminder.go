@@ -0,0 +1,32 @@
+package store
+
+import (
+	"time"
+
+	"github.com/rothskeller/wppsvr/db"
+)
+
+// ReminderSent returns whether a reminder to check in to the specified session
+// has already been sent (or queued to be sent) to the specified call sign.
+func (st *Store) ReminderSent(sessionID int, callsign string) (sent bool) {
+	db.SQL(st.conn, "SELECT 1 FROM reminder WHERE session=? AND callsign=?", func(st *db.St) {
+		st.BindInt(sessionID)
+		st.BindText(callsign)
+		sent = st.Step()
+	})
+	return sent
+}
+
+// MarkReminderSent records that a reminder to check in to the specified
+// session has been sent (or queued to be sent) to the specified call sign.
+func (st *Store) MarkReminderSent(sessionID int, callsign string) {
+	db.Transaction(st.conn, true, func() error {
+		db.SQL(st.conn, "INSERT OR REPLACE INTO reminder (session, callsign, sent) VALUES (?,?,?)", func(st *db.St) {
+			st.BindInt(sessionID)
+			st.BindText(callsign)
+			st.BindTime(time.Now(), sendTimeFormat)
+			st.Step()
+		})
+		return nil
+	})
+}
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"strings"
 	"time"
 
 	"github.com/rothskeller/wppsvr/db"
@@ -18,11 +19,15 @@ type Response struct {
 	SendTime   time.Time `yaml:"sendTime"`
 	SenderCall string    `yaml:"senderCall"`
 	SenderBBS  string    `yaml:"senderBBS"`
+	// DeliveredTime and ReadTime are the times given in delivery and read
+	// receipts that we received for this response, if any.
+	DeliveredTime time.Time `yaml:"deliveredTime"`
+	ReadTime      time.Time `yaml:"readTime"`
 }
 
 // GetResponses retrieves the responses for the specified message.
 func (st *Store) GetResponses(to string) (responses []*Response) {
-	db.SQL(st.conn, "SELECT id, sendto, subject, body, sendtime, sendercall, senderbbs FROM response WHERE responseto=? ORDER BY id", func(st *db.St) {
+	db.SQL(st.conn, "SELECT id, sendto, subject, body, sendtime, sendercall, senderbbs, deliveredtime, readtime FROM response WHERE responseto=? ORDER BY id", func(st *db.St) {
 		st.BindText(to)
 		for st.Step() {
 			var r Response
@@ -35,16 +40,82 @@ func (st *Store) GetResponses(to string) (responses []*Response) {
 			r.SendTime = st.ColumnTime(sendTimeFormat)
 			r.SenderCall = st.ColumnText()
 			r.SenderBBS = st.ColumnText()
+			r.DeliveredTime = st.ColumnTime(sendTimeFormat)
+			r.ReadTime = st.ColumnTime(sendTimeFormat)
 			responses = append(responses, &r)
 		}
 	})
 	return responses
 }
 
+// GetSessionResponses retrieves the responses to all messages in the specified
+// session.
+func (st *Store) GetSessionResponses(sessionID int) (responses []*Response) {
+	db.SQL(st.conn, "SELECT r.id, r.responseto, r.sendto, r.subject, r.body, r.sendtime, r.sendercall, r.senderbbs, r.deliveredtime, r.readtime FROM response r, message m WHERE r.responseto=m.id AND m.session=? ORDER BY r.id", func(st *db.St) {
+		st.BindInt(sessionID)
+		for st.Step() {
+			var r Response
+
+			r.LocalID = st.ColumnText()
+			r.ResponseTo = st.ColumnText()
+			r.To = st.ColumnText()
+			r.Subject = st.ColumnText()
+			r.Body = st.ColumnText()
+			r.SendTime = st.ColumnTime(sendTimeFormat)
+			r.SenderCall = st.ColumnText()
+			r.SenderBBS = st.ColumnText()
+			r.DeliveredTime = st.ColumnTime(sendTimeFormat)
+			r.ReadTime = st.ColumnTime(sendTimeFormat)
+			responses = append(responses, &r)
+		}
+	})
+	return responses
+}
+
+// FindResponse returns the ID of the most recent response with the specified
+// subject that was sent to the specified mailbox (i.e., the part of the
+// address before the "@"), or an empty string if there is none.  It is used to
+// match incoming receipts to the responses they acknowledge.
+func (st *Store) FindResponse(subject, mailbox string) (id string) {
+	// The mailbox is matched with LIKE, for case insensitivity, so any
+	// wildcard characters in it must be escaped.
+	pattern := likeEscaper.Replace(mailbox)
+	db.SQL(st.conn, `SELECT id FROM response WHERE subject=? AND (sendto LIKE ? ESCAPE '\' OR sendto LIKE ? ESCAPE '\') ORDER BY sendtime DESC LIMIT 1`, func(st *db.St) {
+		st.BindText(subject)
+		st.BindText(pattern)
+		st.BindText(pattern + "@%")
+		if st.Step() {
+			id = st.ColumnText()
+		}
+	})
+	return id
+}
+
+// likeEscaper escapes the characters that are special in SQL LIKE patterns.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// AcknowledgeResponse records the receipt of a delivery receipt (if read is
+// false) or a read receipt (if read is true) for the specified response.
+func (st *Store) AcknowledgeResponse(id string, read bool, at time.Time) {
+	var sql = "UPDATE response SET deliveredtime=? WHERE id=?"
+
+	if read {
+		sql = "UPDATE response SET readtime=? WHERE id=?"
+	}
+	db.Transaction(st.conn, true, func() error {
+		db.SQL(st.conn, sql, func(st *db.St) {
+			st.BindTime(at, sendTimeFormat)
+			st.BindText(id)
+			st.Step()
+		})
+		return nil
+	})
+}
+
 // SaveResponse saves an outgoing response to the database.
 func (st *Store) SaveResponse(r *Response) {
 	db.Transaction(st.conn, true, func() error {
-		db.SQL(st.conn, "INSERT INTO response (id, responseto, sendto, subject, body, sendtime, sendercall, senderbbs) VALUES (?,?,?,?,?,?,?,?)", func(st *db.St) {
+		db.SQL(st.conn, "INSERT INTO response (id, responseto, sendto, subject, body, sendtime, sendercall, senderbbs, deliveredtime, readtime) VALUES (?,?,?,?,?,?,?,?,?,?)", func(st *db.St) {
 			st.BindText(r.LocalID)
 			st.BindText(r.ResponseTo)
 			st.BindText(r.To)
@@ -53,6 +124,8 @@ func (st *Store) SaveResponse(r *Response) {
 			st.BindTime(r.SendTime, sendTimeFormat)
 			st.BindText(r.SenderCall)
 			st.BindText(r.SenderBBS)
+			st.BindTime(r.DeliveredTime, sendTimeFormat)
+			st.BindTime(r.ReadTime, sendTimeFormat)
 			st.Step()
 		})
 		return nil
@@ -1,5 +1,38 @@
 -- Database schema for the packet-checkins application.
 
+-- The announcement table records the posting of each session's announcement
+-- to each of its announcement addresses, when the session opens.  error is
+-- empty if the announcement was sent successfully.
+CREATE TABLE announcement (
+    session integer  NOT NULL REFERENCES session ON DELETE CASCADE,
+    sendto  text     NOT NULL,
+    sent    datetime NOT NULL,
+    error   text     NOT NULL,
+    PRIMARY KEY (session, sendto)
+);
+
+-- The exercise table records the sending of each two-way exercise session's
+-- exercise message to each participant, when the session opens.  Each copy has
+-- its own message number, which the participant's reply must reference.  error
+-- is empty if the message was sent successfully.
+CREATE TABLE exercise (
+    session  integer  NOT NULL REFERENCES session ON DELETE CASCADE,
+    callsign text     NOT NULL,
+    msgid    text     NOT NULL,
+    sendto   text     NOT NULL,
+    sent     datetime NOT NULL,
+    error    text     NOT NULL,
+    PRIMARY KEY (session, callsign)
+);
+CREATE INDEX exercise_msgid_idx ON exercise (session, msgid);
+
+-- The feedtoken table stores the tokens that identify participants in the URLs
+-- of their personal feeds, which are fetched without logging in.
+CREATE TABLE feedtoken (
+    callsign text PRIMARY KEY,
+    token    text NOT NULL UNIQUE
+) WITHOUT ROWID;
+
 -- The login table stores login information for currently logged in users.
 CREATE TABLE login (
   token    text     PRIMARY KEY,
@@ -22,6 +55,7 @@ CREATE TABLE message (
     messagetype  text     NOT NULL,
 	score        integer  NOT NULL,
 	summary      text     NOT NULL,
+	problems     text     NOT NULL,
 	analysis     text     NOT NULL
 );
 CREATE INDEX message_session_idx ON message (session);
@@ -33,16 +67,74 @@ CREATE TABLE msgnum (
     num    integer NOT NULL
 ) WITHOUT ROWID;
 
+-- The participant table stores preferences of participants, set through their
+-- profile pages.  email is used only once confirmed; until then, the address
+-- is in pendingemail, and emailtoken is the secret in the confirmation link
+-- sent to it.
+CREATE TABLE participant (
+    callsign     text    PRIMARY KEY,
+    email        text    NOT NULL,
+    pendingemail text    NOT NULL,
+    emailtoken   text    NOT NULL,
+    reportcard   text    NOT NULL,
+    noreminders  boolean NOT NULL
+) WITHOUT ROWID;
+CREATE INDEX participant_emailtoken_idx ON participant (emailtoken) WHERE emailtoken<>'';
+
+-- The outbox table holds messages queued to be sent from the mailboxes of
+-- sessions, such as missed-you messages.  sent is NULL until the message has
+-- been sent; until then, each step tries again to send it.
+CREATE TABLE outbox (
+    id      integer  PRIMARY KEY,
+    session integer  NOT NULL REFERENCES session ON DELETE CASCADE,
+    sendto  text     NOT NULL,
+    subject text     NOT NULL,
+    body    text     NOT NULL,
+    queued  datetime NOT NULL,
+    sent    datetime
+);
+CREATE INDEX outbox_sent_idx ON outbox (sent);
+
+-- The periodic table records which periodic mailings (e.g., monthly summary
+-- reports) have been sent for which periods.  Report cards are also recorded
+-- individually, with kinds like "reportcard-monthly:KC6RSC".
+CREATE TABLE periodic (
+    kind   text     NOT NULL,
+    period text     NOT NULL,
+    sent   datetime NOT NULL,
+    PRIMARY KEY (kind, period)
+) WITHOUT ROWID;
+
+-- The published table records the publication of each session's final report
+-- in the report feed, with a one-line synopsis of its statistics.
+CREATE TABLE published (
+    session   integer  PRIMARY KEY REFERENCES session ON DELETE CASCADE,
+    published datetime NOT NULL,
+    synopsis  text     NOT NULL
+);
+CREATE INDEX published_published_idx ON published (published);
+
+-- The reminder table records the reminders queued for regular participants who
+-- hadn't yet checked in to a session, so that none is sent twice.
+CREATE TABLE reminder (
+    session  integer  NOT NULL REFERENCES session ON DELETE CASCADE,
+    callsign text     NOT NULL,
+    sent     datetime NOT NULL,
+    PRIMARY KEY (session, callsign)
+);
+
 -- The response table stores all outgoing responses to incoming messages.
 CREATE TABLE response (
-	id         text     PRIMARY KEY,
-    responseto text     NOT NULL REFERENCES message ON DELETE CASCADE,
-    sendto     text     NOT NULL,
-    subject    text     NOT NULL,
-    body       text     NOT NULL,
-    sendtime   datetime NOT NULL,
-    sendercall text     NOT NULL,
-    senderbbs  text     NOT NULL
+    id            text     PRIMARY KEY,
+    responseto    text     NOT NULL REFERENCES message ON DELETE CASCADE,
+    sendto        text     NOT NULL,
+    subject       text     NOT NULL,
+    body          text     NOT NULL,
+    sendtime      datetime NOT NULL,
+    sendercall    text     NOT NULL,
+    senderbbs     text     NOT NULL,
+    deliveredtime datetime NOT NULL,
+    readtime      datetime NOT NULL
 );
 
 -- The retrieval table contains a row for each scheduled retrieval for each
@@ -55,6 +147,17 @@ CREATE TABLE retrieval (
 );
 CREATE INDEX retrieval_session_idx ON retrieval (session);
 
+-- The sessionstats table holds the version number of each session, which is
+-- changed whenever the session or its messages are saved, and the statistics
+-- shown on the front page and calendar, cached as of that version.  The
+-- statistics are NULL when they need to be recomputed.
+CREATE TABLE sessionstats (
+    session  integer PRIMARY KEY REFERENCES session ON DELETE CASCADE,
+    version  integer NOT NULL,
+    checkins integer,
+    avgscore integer
+);
+
 -- The session table describes all sessions.
 CREATE TABLE session (
     id                integer  PRIMARY KEY,
@@ -72,7 +175,18 @@ CREATE TABLE session (
     instructions      text     NOT NULL,
     retrieveat        text     NOT NULL,
     report            text     NOT NULL,
-    flags             integer  NOT NULL
+    flags             integer  NOT NULL,
+    fieldweights      text     NOT NULL,
+    receipttext       text     NOT NULL,
+    announceto        text     NOT NULL,
+    announcetext      text     NOT NULL,
+    triggerat         datetime NOT NULL,
+    triggerto         text     NOT NULL,
+    triggertext       text     NOT NULL,
+    triggersent       datetime NOT NULL,
+    exercisemsg       text     NOT NULL,
+    exerciseto        text     NOT NULL,
+    route             text     NOT NULL
 );
 CREATE UNIQUE INDEX session_call_end_idx ON session (callsign, end);
 CREATE INDEX session_end_idx ON session (end);
@@ -13,6 +13,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -25,25 +26,37 @@ import (
 
 // A Session defines the parameters of a single session instance.
 type Session struct {
-	ID           int          `yaml:"id"`
-	CallSign     string       `yaml:"callSign"`
-	Name         string       `yaml:"name"`
-	Prefix       string       `yaml:"prefix"`
-	Start        time.Time    `yaml:"start"`
-	End          time.Time    `yaml:"end"`
-	ReportToText []string     `yaml:"-"`
-	ReportToHTML []string     `yaml:"-"`
-	ToBBSes      []string     `yaml:"toBBSes"`
-	DownBBSes    []string     `yaml:"downBBSes"`
-	Retrieve     []*Retrieval `yaml:"retrieve"`
-	MessageTypes []string     `yaml:"messageTypes"`
-	ModelMessage string       `yaml:"modelMessage"`
-	Instructions string       `yaml:"instructions"`
-	RetrieveAt   string       `yaml:"retrieveAt"`
-	Report       string       `yaml:"-"`
-	Flags        SessionFlags `yaml:"flags"`
+	ID           int            `yaml:"id"`
+	CallSign     string         `yaml:"callSign"`
+	Name         string         `yaml:"name"`
+	Prefix       string         `yaml:"prefix"`
+	Start        time.Time      `yaml:"start"`
+	End          time.Time      `yaml:"end"`
+	ReportToText []string       `yaml:"-"`
+	ReportToHTML []string       `yaml:"-"`
+	ToBBSes      []string       `yaml:"toBBSes"`
+	DownBBSes    []string       `yaml:"downBBSes"`
+	Retrieve     []*Retrieval   `yaml:"retrieve"`
+	MessageTypes []string       `yaml:"messageTypes"`
+	ModelMessage string         `yaml:"modelMessage"`
+	Instructions string         `yaml:"instructions"`
+	RetrieveAt   string         `yaml:"retrieveAt"`
+	Report       string         `yaml:"-"`
+	Flags        SessionFlags   `yaml:"flags"`
+	FieldWeights map[string]int `yaml:"fieldWeights"`
+	ReceiptText  string         `yaml:"receiptText"`
+	AnnounceTo   []string       `yaml:"announceTo"`
+	AnnounceText string         `yaml:"announceText"`
+	TriggerAt    time.Time      `yaml:"triggerAt"`
+	TriggerTo    []string       `yaml:"triggerTo"`
+	TriggerText  string         `yaml:"triggerText"`
+	TriggerSent  time.Time      `yaml:"triggerSent"`
+	Exercise     string         `yaml:"exercise"`
+	ExerciseTo   []string       `yaml:"exerciseTo"`
+	Route        []string       `yaml:"route"`
 
 	ModelMsg         message.Message   `yaml:"-"`
+	ExerciseMsg      message.Message   `yaml:"-"`
 	RetrieveInterval interval.Interval `yaml:"-"`
 }
 
@@ -54,7 +67,7 @@ type Retrieval struct {
 }
 
 // SessionFlags is a collection of flags describing a session.
-type SessionFlags uint8
+type SessionFlags uint16
 
 // Values for SessionFlags
 const (
@@ -65,6 +78,8 @@ const (
 	Imported
 	Modified
 	ReportToSenders
+	CheckBBSAssignment
+	SendMissedYou
 )
 
 const (
@@ -125,7 +140,7 @@ func (s *Store) GetSessions(start, end time.Time) (list []*Session) {
 // specified criteria.
 func (s *Store) getSessionsWhere(where string, args ...interface{}) (list []*Session) {
 	db.Transaction(s.conn, false, func() error {
-		db.SQL(s.conn, "SELECT id, callsign, name, prefix, start, end, reporttotext, reporttohtml, tobbses, downbbses, messagetypes, modelmessage, instructions, retrieveat, report, flags FROM session WHERE "+where, func(st *db.St) {
+		db.SQL(s.conn, "SELECT id, callsign, name, prefix, start, end, reporttotext, reporttohtml, tobbses, downbbses, messagetypes, modelmessage, instructions, retrieveat, report, flags, fieldweights, receipttext, announceto, announcetext, triggerat, triggerto, triggertext, triggersent, exercisemsg, exerciseto, route FROM session WHERE "+where, func(st *db.St) {
 			for _, arg := range args {
 				switch arg := arg.(type) {
 				case int:
@@ -153,6 +168,17 @@ func (s *Store) getSessionsWhere(where string, args ...interface{}) (list []*Ses
 				session.RetrieveAt = st.ColumnText()
 				session.Report = st.ColumnText()
 				session.Flags = SessionFlags(st.ColumnInt())
+				session.FieldWeights = splitWeights(st.ColumnText())
+				session.ReceiptText = st.ColumnText()
+				session.AnnounceTo = split(st.ColumnText())
+				session.AnnounceText = st.ColumnText()
+				session.TriggerAt = st.ColumnTime(startEndFormat)
+				session.TriggerTo = split(st.ColumnText())
+				session.TriggerText = st.ColumnText()
+				session.TriggerSent = st.ColumnTime(startEndFormat)
+				session.Exercise = st.ColumnText()
+				session.ExerciseTo = split(st.ColumnText())
+				session.Route = split(st.ColumnText())
 				session.RetrieveInterval = interval.Parse(session.RetrieveAt)
 				if session.ModelMessage != "" {
 					if env, body, err := envelope.ParseSaved(session.ModelMessage); err == nil {
@@ -161,6 +187,13 @@ func (s *Store) getSessionsWhere(where string, args ...interface{}) (list []*Ses
 						panic(err)
 					}
 				}
+				if session.Exercise != "" {
+					if env, body, err := envelope.ParseSaved(session.Exercise); err == nil {
+						session.ExerciseMsg = message.Decode(env, body)
+					} else {
+						panic(err)
+					}
+				}
 				db.SQL(s.conn, "SELECT bbs, lastrun FROM retrieval WHERE session=?", func(s2 *db.St) {
 					s2.BindInt(session.ID)
 					for s2.Step() {
@@ -179,10 +212,36 @@ func (s *Store) getSessionsWhere(where string, args ...interface{}) (list []*Ses
 	return list
 }
 
+// splitWeights parses a list of field weights in the form
+// "label=weight;label=weight".
+func splitWeights(s string) (weights map[string]int) {
+	for _, pair := range split(s) {
+		if idx := strings.LastIndexByte(pair, '='); idx > 0 {
+			if w, err := strconv.Atoi(pair[idx+1:]); err == nil {
+				if weights == nil {
+					weights = make(map[string]int)
+				}
+				weights[pair[:idx]] = w
+			}
+		}
+	}
+	return weights
+}
+
+// joinWeights is the inverse of splitWeights.
+func joinWeights(weights map[string]int) string {
+	var pairs = make([]string, 0, len(weights))
+	for label, w := range weights {
+		pairs = append(pairs, fmt.Sprintf("%s=%d", label, w))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ";")
+}
+
 // CreateSession creates a new session.
 func (s *Store) CreateSession(session *Session) {
 	db.Transaction(s.conn, true, func() error {
-		db.SQL(s.conn, "INSERT INTO session (callsign, name, prefix, start, end, reporttotext, reporttohtml, tobbses, downbbses, messagetypes, modelmessage, instructions, retrieveat, report, flags) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)", func(st *db.St) {
+		db.SQL(s.conn, "INSERT INTO session (callsign, name, prefix, start, end, reporttotext, reporttohtml, tobbses, downbbses, messagetypes, modelmessage, instructions, retrieveat, report, flags, fieldweights, receipttext, announceto, announcetext, triggerat, triggerto, triggertext, triggersent, exercisemsg, exerciseto, route) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)", func(st *db.St) {
 			st.BindText(session.CallSign)
 			st.BindText(session.Name)
 			st.BindText(session.Prefix)
@@ -198,6 +257,17 @@ func (s *Store) CreateSession(session *Session) {
 			st.BindText(session.RetrieveAt)
 			st.BindText(session.Report)
 			st.BindInt(int(session.Flags))
+			st.BindText(joinWeights(session.FieldWeights))
+			st.BindText(session.ReceiptText)
+			st.BindText(strings.Join(session.AnnounceTo, ";"))
+			st.BindText(session.AnnounceText)
+			st.BindTime(session.TriggerAt, startEndFormat)
+			st.BindText(strings.Join(session.TriggerTo, ";"))
+			st.BindText(session.TriggerText)
+			st.BindTime(session.TriggerSent, startEndFormat)
+			st.BindText(session.Exercise)
+			st.BindText(strings.Join(session.ExerciseTo, ";"))
+			st.BindText(strings.Join(session.Route, ";"))
 			st.Step()
 		})
 		session.ID = int(s.conn.LastInsertRowID())
@@ -222,7 +292,7 @@ func (s *Store) UpdateSession(session *Session) {
 		return
 	}
 	db.Transaction(s.conn, true, func() error {
-		db.SQL(s.conn, "UPDATE session SET (callsign, name, prefix, start, end, reporttotext, reporttohtml, tobbses, downbbses, messagetypes, modelmessage, instructions, retrieveat, report, flags) = (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?) WHERE id=?", func(st *db.St) {
+		db.SQL(s.conn, "UPDATE session SET (callsign, name, prefix, start, end, reporttotext, reporttohtml, tobbses, downbbses, messagetypes, modelmessage, instructions, retrieveat, report, flags, fieldweights, receipttext, announceto, announcetext, triggerat, triggerto, triggertext, triggersent, exercisemsg, exerciseto, route) = (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?) WHERE id=?", func(st *db.St) {
 			st.BindText(session.CallSign)
 			st.BindText(session.Name)
 			st.BindText(session.Prefix)
@@ -238,6 +308,17 @@ func (s *Store) UpdateSession(session *Session) {
 			st.BindText(session.RetrieveAt)
 			st.BindText(session.Report)
 			st.BindInt(int(session.Flags))
+			st.BindText(joinWeights(session.FieldWeights))
+			st.BindText(session.ReceiptText)
+			st.BindText(strings.Join(session.AnnounceTo, ";"))
+			st.BindText(session.AnnounceText)
+			st.BindTime(session.TriggerAt, startEndFormat)
+			st.BindText(strings.Join(session.TriggerTo, ";"))
+			st.BindText(session.TriggerText)
+			st.BindTime(session.TriggerSent, startEndFormat)
+			st.BindText(session.Exercise)
+			st.BindText(strings.Join(session.ExerciseTo, ";"))
+			st.BindText(strings.Join(session.Route, ";"))
 			st.BindInt(session.ID)
 			st.Step()
 		})
@@ -254,6 +335,7 @@ func (s *Store) UpdateSession(session *Session) {
 				st.Reset()
 			}
 		})
+		bumpSessionVersion(s.conn, session.ID)
 		return nil
 	})
 }
@@ -269,10 +351,31 @@ func (s *Store) DeleteSession(session *Session) {
 	})
 }
 
+// Model images are kept in files in the working directory.  Images uploaded by
+// the session editor are named s<ID>p<N>.<ext>, where N is the page number.
+// The rendering of the model message, made whenever the session is saved, is
+// named s<ID>m.pdf.  Uploaded images, if any, take precedence over the
+// rendering.
+
 // ModelImageCount returns the number of model images associated with the
 // session.  The images use 1-based numbering, so they are numbered 1 through
 // the return value of this function, inclusive.
 func (s *Store) ModelImageCount(sid int) (count int) {
+	if count = uploadedModelImageCount(sid); count == 0 {
+		if _, err := os.Stat(renderedModelImage(sid)); err == nil {
+			count = 1
+		}
+	}
+	return count
+}
+
+// ModelImagesUploaded returns whether the model images for the session were
+// uploaded, rather than rendered from its model message.
+func (s *Store) ModelImagesUploaded(sid int) bool {
+	return uploadedModelImageCount(sid) != 0
+}
+
+func uploadedModelImageCount(sid int) (count int) {
 	prefix := fmt.Sprintf("s%d", sid)
 	matches, _ := filepath.Glob(prefix + "p*.*")
 	for _, match := range matches {
@@ -284,10 +387,20 @@ func (s *Store) ModelImageCount(sid int) (count int) {
 	return count
 }
 
+func renderedModelImage(sid int) string {
+	return fmt.Sprintf("s%dm.pdf", sid)
+}
+
 // ModelImage returns an open file handle to the specified model image page
 // number, or nil if there is no such image.  Model image page numbers start at
 // 1.  It is the caller's responsibility to close the handle.
 func (s *Store) ModelImage(sid int, pnum int) (fh *os.File) {
+	if uploadedModelImageCount(sid) == 0 {
+		if pnum == 1 {
+			fh, _ = os.Open(renderedModelImage(sid))
+		}
+		return fh
+	}
 	matches, _ := filepath.Glob(fmt.Sprintf("s%dp%d.*", sid, pnum))
 	if len(matches) == 1 {
 		fh, _ = os.Open(matches[0])
@@ -295,8 +408,17 @@ func (s *Store) ModelImage(sid int, pnum int) (fh *os.File) {
 	return fh
 }
 
-// DeleteModelImages removes all model images for the specified session.
+// DeleteModelImages removes all model images for the specified session, both
+// uploaded and rendered.
 func (s *Store) DeleteModelImages(sid int) {
+	s.DeleteUploadedModelImages(sid)
+	os.Remove(renderedModelImage(sid))
+}
+
+// DeleteUploadedModelImages removes the uploaded model images for the
+// specified session, so that the rendering of its model message is used
+// instead.
+func (s *Store) DeleteUploadedModelImages(sid int) {
 	prefix := fmt.Sprintf("s%d", sid)
 	matches, _ := filepath.Glob(prefix + "p*.*")
 	for _, match := range matches {
@@ -304,7 +426,8 @@ func (s *Store) DeleteModelImages(sid int) {
 	}
 }
 
-// SaveModelImage saves the specified model image for the specified session.
+// SaveModelImage saves the specified uploaded model image for the specified
+// session.
 func (s *Store) SaveModelImage(sid int, pnum int, name string, body io.Reader) {
 	fname := fmt.Sprintf("s%dp%d%s", sid, pnum, filepath.Ext(name))
 	if fh, err := os.Create(fname); err == nil {
@@ -312,3 +435,18 @@ func (s *Store) SaveModelImage(sid int, pnum int, name string, body io.Reader) {
 		fh.Close()
 	}
 }
+
+// RenderModelImage replaces the rendering of the model message for the
+// specified session with a PDF rendering of the supplied model message.  If
+// the message can't be rendered, the session is left with no rendering.
+func (s *Store) RenderModelImage(sid int, msg message.Message) error {
+	os.Remove(renderedModelImage(sid))
+	if !msg.PDFRenderable() {
+		return fmt.Errorf("%s forms cannot be rendered", msg.Base().Type.Name)
+	}
+	if err := msg.RenderPDF(new(envelope.Envelope), renderedModelImage(sid)); err != nil {
+		os.Remove(renderedModelImage(sid))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"time"
+
+	"github.com/rothskeller/wppsvr/db"
+	"zombiezen.com/go/sqlite"
+)
+
+// SessionStats contains the statistics about a session that are shown on the
+// front page and calendar.  They are cached in the database so that those pages
+// don't need to generate a report for every session they show.
+type SessionStats struct {
+	CheckIns     int
+	AverageScore int
+}
+
+// SessionVersion returns the version of the specified session.  The version
+// changes whenever the session or any of its messages are saved.  Anything
+// derived from the session and its messages (e.g., a report on it) remains
+// valid for as long as the session version is unchanged.
+func (st *Store) SessionVersion(sessionID int) (version int) {
+	db.SQL(st.conn, "SELECT version FROM sessionstats WHERE session=?", func(st *db.St) {
+		st.BindInt(sessionID)
+		if st.Step() {
+			version = st.ColumnInt()
+		}
+	})
+	return version
+}
+
+// bumpSessionVersion changes the version of the specified session, and
+// discards its cached statistics since they are now out of date.  It must be
+// called within a transaction.
+func bumpSessionVersion(conn *sqlite.Conn, sessionID int) {
+	db.SQL(conn, "INSERT INTO sessionstats (session, version) VALUES (?,1) ON CONFLICT (session) DO UPDATE SET version=version+1, checkins=NULL, avgscore=NULL", func(st *db.St) {
+		st.BindInt(sessionID)
+		st.Step()
+	})
+}
+
+// GetSessionStats returns the cached statistics for those sessions that end
+// during the specified time range (inclusive start, exclusive end) and have
+// current statistics cached.  The returned map is keyed by session ID.
+func (st *Store) GetSessionStats(start, end time.Time) (stats map[int]*SessionStats) {
+	stats = make(map[int]*SessionStats)
+	db.SQL(st.conn, "SELECT ss.session, ss.checkins, ss.avgscore FROM sessionstats ss, session s WHERE ss.session=s.id AND s.end>=? AND s.end<? AND ss.checkins IS NOT NULL", func(st *db.St) {
+		st.BindTime(start, startEndFormat)
+		st.BindTime(end, startEndFormat)
+		for st.Step() {
+			var id = st.ColumnInt()
+			stats[id] = &SessionStats{CheckIns: st.ColumnInt(), AverageScore: st.ColumnInt()}
+		}
+	})
+	return stats
+}
+
+// SaveSessionStats caches the statistics for the specified session.  version is
+// the session version from which the statistics were computed; if the session
+// has changed since then, the statistics are not saved.
+func (st *Store) SaveSessionStats(sessionID, version int, stats *SessionStats) {
+	db.Transaction(st.conn, true, func() error {
+		db.SQL(st.conn, "INSERT INTO sessionstats (session, version, checkins, avgscore) VALUES (?,?,?,?) ON CONFLICT (session) DO UPDATE SET checkins=excluded.checkins, avgscore=excluded.avgscore WHERE version=excluded.version", func(st *db.St) {
+			st.BindInt(sessionID)
+			st.BindInt(version)
+			st.BindInt(stats.CheckIns)
+			st.BindInt(stats.AverageScore)
+			st.Step()
+		})
+		return nil
+	})
+}
@@ -0,0 +1,85 @@
+package store_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+
+	"github.com/rothskeller/wppsvr/report"
+	"github.com/rothskeller/wppsvr/store"
+)
+
+// openTestStore creates an empty database in a temporary directory, and opens
+// it.
+func openTestStore(t *testing.T) *store.Store {
+	var dir = t.TempDir()
+
+	schema, err := os.ReadFile("schema.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := sqlite.OpenConn(filepath.Join(dir, "wppsvr.db"), sqlite.OpenReadWrite|sqlite.OpenCreate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sqlitex.ExecuteScript(conn, string(schema), nil)
+	conn.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cwd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(cwd)
+	st, err := store.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return st
+}
+
+func TestSessionVersion(t *testing.T) {
+	var st = openTestStore(t)
+
+	session := &store.Session{
+		CallSign: "PKTTUE",
+		Name:     "SVECS Net",
+		Prefix:   "TUE",
+		Start:    time.Date(2022, 4, 13, 0, 0, 0, 0, time.Local),
+		End:      time.Date(2022, 4, 19, 20, 0, 0, 0, time.Local),
+	}
+	st.CreateSession(session)
+	version := st.SessionVersion(session.ID)
+	r1 := report.Cached(st, session)
+	if r2 := report.Cached(st, session); r2 != r1 {
+		t.Error("report regenerated for unchanged session")
+	}
+	// Saving a message changes the version, so the report is generated
+	// anew.
+	st.SaveMessage(&store.Message{
+		LocalID: "TUE-100P", Hash: "abc", Session: session.ID, FromAddress: "kc6rsc@w1xsc.ampr.org",
+		FromCallSign: "KC6RSC", FromBBS: "W1XSC", ToBBS: "W4XSC", MessageType: "plain", Score: 100,
+		DeliveryTime: time.Date(2022, 4, 19, 19, 0, 0, 0, time.Local),
+	})
+	if v := st.SessionVersion(session.ID); v == version {
+		t.Error("SaveMessage did not change the session version")
+	} else {
+		version = v
+	}
+	r2 := report.Cached(st, session)
+	if r2 == r1 || r2.ValidCount != 1 {
+		t.Error("report not regenerated after SaveMessage")
+	}
+	// So does updating the session.
+	session.Name = "SVECS Practice Net"
+	st.UpdateSession(session)
+	if v := st.SessionVersion(session.ID); v == version {
+		t.Error("UpdateSession did not change the session version")
+	}
+	if r3 := report.Cached(st, session); r3 == r2 || r3.SessionName != "SVECS Practice Net" {
+		t.Error("report not regenerated after UpdateSession")
+	}
+}
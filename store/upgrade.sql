@@ -0,0 +1,115 @@
+-- Statements that upgrade a database created from an earlier version of
+-- schema.sql.  Each group applies one schema change, in the order they were
+-- made; run (with sqlite3 wppsvr.db) the groups for the changes made since the
+-- database was created.  New columns get defaults meaning "none" or "not set".
+
+-- Problem codes of received messages.
+ALTER TABLE message ADD COLUMN problems text NOT NULL DEFAULT '';
+
+-- Field weights of sessions.
+ALTER TABLE session ADD COLUMN fieldweights text NOT NULL DEFAULT '';
+
+-- Delivery receipt text of sessions.
+ALTER TABLE session ADD COLUMN receipttext text NOT NULL DEFAULT '';
+
+-- Delivery and read receipts for responses.
+ALTER TABLE response ADD COLUMN deliveredtime datetime NOT NULL DEFAULT '';
+ALTER TABLE response ADD COLUMN readtime datetime NOT NULL DEFAULT '';
+
+-- Periodic mailings.
+CREATE TABLE periodic (
+    kind   text     NOT NULL,
+    period text     NOT NULL,
+    sent   datetime NOT NULL,
+    PRIMARY KEY (kind, period)
+) WITHOUT ROWID;
+
+-- Participant preferences.
+CREATE TABLE participant (
+    callsign   text PRIMARY KEY,
+    email      text NOT NULL,
+    reportcard text NOT NULL
+) WITHOUT ROWID;
+
+-- Session versions and cached statistics.
+CREATE TABLE sessionstats (
+    session  integer PRIMARY KEY REFERENCES session ON DELETE CASCADE,
+    version  integer NOT NULL,
+    checkins integer,
+    avgscore integer
+);
+
+-- Feed tokens.
+CREATE TABLE feedtoken (
+    callsign text PRIMARY KEY,
+    token    text NOT NULL UNIQUE
+) WITHOUT ROWID;
+
+-- Published session reports.
+CREATE TABLE published (
+    session   integer  PRIMARY KEY REFERENCES session ON DELETE CASCADE,
+    published datetime NOT NULL,
+    synopsis  text     NOT NULL
+);
+CREATE INDEX published_published_idx ON published (published);
+
+-- Outbox of queued messages.
+CREATE TABLE outbox (
+    id      integer  PRIMARY KEY,
+    session integer  NOT NULL REFERENCES session ON DELETE CASCADE,
+    sendto  text     NOT NULL,
+    subject text     NOT NULL,
+    body    text     NOT NULL,
+    queued  datetime NOT NULL,
+    sent    datetime
+);
+CREATE INDEX outbox_sent_idx ON outbox (sent);
+
+-- Session announcements.
+CREATE TABLE announcement (
+    session integer  NOT NULL REFERENCES session ON DELETE CASCADE,
+    sendto  text     NOT NULL,
+    sent    datetime NOT NULL,
+    error   text     NOT NULL,
+    PRIMARY KEY (session, sendto)
+);
+ALTER TABLE session ADD COLUMN announceto text NOT NULL DEFAULT '';
+ALTER TABLE session ADD COLUMN announcetext text NOT NULL DEFAULT '';
+
+-- Check-in reminders.
+ALTER TABLE participant ADD COLUMN noreminders boolean NOT NULL DEFAULT 0;
+CREATE TABLE reminder (
+    session  integer  NOT NULL REFERENCES session ON DELETE CASCADE,
+    callsign text     NOT NULL,
+    sent     datetime NOT NULL,
+    PRIMARY KEY (session, callsign)
+);
+
+-- Timed drill triggers.
+ALTER TABLE session ADD COLUMN triggerat datetime NOT NULL DEFAULT '';
+ALTER TABLE session ADD COLUMN triggerto text NOT NULL DEFAULT '';
+ALTER TABLE session ADD COLUMN triggertext text NOT NULL DEFAULT '';
+ALTER TABLE session ADD COLUMN triggersent datetime NOT NULL DEFAULT '';
+
+-- Two-way exercises.
+CREATE TABLE exercise (
+    session  integer  NOT NULL REFERENCES session ON DELETE CASCADE,
+    callsign text     NOT NULL,
+    msgid    text     NOT NULL,
+    sendto   text     NOT NULL,
+    sent     datetime NOT NULL,
+    error    text     NOT NULL,
+    PRIMARY KEY (session, callsign)
+);
+CREATE INDEX exercise_msgid_idx ON exercise (session, msgid);
+ALTER TABLE session ADD COLUMN exercisemsg text NOT NULL DEFAULT '';
+ALTER TABLE session ADD COLUMN exerciseto text NOT NULL DEFAULT '';
+
+-- Relay exercise routes.
+ALTER TABLE session ADD COLUMN route text NOT NULL DEFAULT '';
+
+-- Confirmation of participants' email addresses.  Addresses given before this
+-- change are treated as confirmed.
+ALTER TABLE participant ADD COLUMN pendingemail text NOT NULL DEFAULT '';
+ALTER TABLE participant ADD COLUMN emailtoken text NOT NULL DEFAULT '';
+CREATE INDEX participant_emailtoken_idx ON participant (emailtoken) WHERE emailtoken<>'';
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/rothskeller/wppsvr/config"
+	"github.com/rothskeller/wppsvr/report"
+	"github.com/rothskeller/wppsvr/store"
+)
+
+// sendSummaries sends the monthly and yearly summary reports, if they are
+// configured and haven't already been sent.  They cover the previous month and
+// the previous year, respectively.
+func sendSummaries(st *store.Store) {
+	var (
+		conf      = config.Get().SummaryReports
+		now       = time.Now()
+		thisMonth = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+		thisYear  = time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.Local)
+	)
+	if len(conf.Monthly) != 0 {
+		sendSummary(st, "monthly", thisMonth.AddDate(0, -1, 0), thisMonth, conf.Monthly)
+	}
+	if len(conf.Yearly) != 0 {
+		sendSummary(st, "yearly", thisYear.AddDate(-1, 0, 0), thisYear, conf.Yearly)
+	}
+}
+
+// sendSummary sends a summary report for the specified period, unless it has
+// already been sent.  Summaries more than a week overdue are not sent, so that
+// turning them on doesn't send a stale one.
+func sendSummary(st *store.Store, kind string, start, end time.Time, to []string) {
+	var period = start.Format("2006-01-02")
+
+	if time.Since(end) > 7*24*time.Hour || st.PeriodicSent(kind, period) {
+		return
+	}
+	// Don't send the summary until all of the sessions in the period are
+	// closed.
+	for _, session := range st.GetSessions(start, end) {
+		if session.Flags&store.Running != 0 {
+			return
+		}
+	}
+	// If sending fails, the period isn't marked as sent, so that another
+	// attempt will be made later.
+	summary := report.GenerateSummary(st, start, end)
+	if err := summary.SendHTML(append([]string(nil), to...)); err != nil {
+		log.Printf("ERROR: sending %s summary for %s: %s", kind, summary.Period, err)
+		return
+	}
+	log.Printf("Sent %s summary for %s.", kind, summary.Period)
+	st.MarkPeriodicSent(kind, period)
+}
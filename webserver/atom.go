@@ -0,0 +1,101 @@
+package webserver
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/rothskeller/wppsvr/config"
+)
+
+// atomFeedLength is the maximum number of entries in the report feed.
+const atomFeedLength = 50
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	ID        string       `xml:"id"`
+	Title     string       `xml:"title"`
+	Updated   string       `xml:"updated"`
+	Published string       `xml:"published"`
+	Link      atomLink     `xml:"link"`
+	Summary   string       `xml:"summary"`
+	Content   *atomContent `xml:"content"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// serveAtom handles GET /reports.atom requests.  It returns an Atom feed of
+// the final reports of sessions, as they are published when the sessions
+// close.  A "name" parameter limits the feed to sessions with that name.  A
+// "token" parameter, giving a participant's personal feed token (see
+// store.GetFeedToken), adds the full text of each report to its entry.
+func (ws *webserver) serveAtom(w http.ResponseWriter, r *http.Request) {
+	var (
+		full bool
+		feed atomFeed
+		name = r.FormValue("name")
+		base = config.Get().ServerURL
+	)
+	if token := r.FormValue("token"); token != "" {
+		if ws.st.GetFeedCallSign(token) == "" {
+			http.Error(w, "404 Not Found", http.StatusNotFound)
+			return
+		}
+		full = true
+	}
+	feed.ID = base + "/reports.atom"
+	feed.Title = "Weekly Packet Practice Reports"
+	if name != "" {
+		feed.ID += "?name=" + url.QueryEscape(name)
+		feed.Title = name + " Reports"
+	}
+	feed.Author.Name = "Santa Clara County ARES/RACES"
+	feed.Links = []atomLink{{Rel: "self", Href: feed.ID}, {Href: base + "/"}}
+	feed.Updated = time.Now().UTC().Format(time.RFC3339)
+	for i, pr := range ws.st.GetPublishedReports(name, atomFeedLength) {
+		var link = fmt.Sprintf("%s/report?session=%d", base, pr.SessionID)
+		var entry = atomEntry{
+			ID:        link,
+			Title:     fmt.Sprintf("%s — %s", pr.SessionName, pr.SessionEnd.Format("Monday, January 2, 2006")),
+			Updated:   pr.Published.UTC().Format(time.RFC3339),
+			Published: pr.Published.UTC().Format(time.RFC3339),
+			Link:      atomLink{Href: link},
+			Summary:   pr.Synopsis,
+		}
+		if full {
+			entry.Content = &atomContent{Type: "text", Body: pr.Report}
+		}
+		if i == 0 {
+			feed.Updated = entry.Updated
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	enc.Encode(&feed)
+}
@@ -9,7 +9,6 @@ import (
 	"time"
 
 	"github.com/rothskeller/wppsvr/htmlb"
-	"github.com/rothskeller/wppsvr/report"
 	"github.com/rothskeller/wppsvr/store"
 )
 
@@ -80,6 +79,11 @@ func (ws *webserver) serveCalendar(w http.ResponseWriter, r *http.Request) {
 	for month := time.January; month <= time.December; month++ {
 		ws.serveCalendarMonth(calendar, year, month, view)
 	}
+	html.E("a id=stats href=/stats?year=%d>View Problem Statistics", year)
+	html.E("a id=summary href=/summary?year=%d>View Summary for %d", year, year)
+	html.E("a id=profile href=/profile>Your Preferences and Feeds")
+	html.E("a id=ics href=/sessions.ics>Session Calendar Feed")
+	html.E("a id=atom href=/reports.atom>Session Report Feed")
 	// Give a link to the session editor, for those who can use it.
 	if canEditSessions(callsign) {
 		html.E("a id=edit href=/sessions>Edit Practice Session Definitions")
@@ -107,11 +111,14 @@ func (ws *webserver) serveCalendarMonth(calendar *htmlb.Element, year int, month
 	var (
 		date     time.Time
 		sessions []*store.Session
+		stats    map[int]*store.SessionStats
+		start    = time.Date(year, month, 1, 0, 0, 0, 0, time.Local)
+		end      = time.Date(year, month+1, 1, 0, 0, 0, 0, time.Local)
 	)
-	sessions = ws.st.GetSessions(
-		time.Date(year, month, 1, 0, 0, 0, 0, time.Local),
-		time.Date(year, month+1, 1, 0, 0, 0, 0, time.Local),
-	)
+	sessions = ws.st.GetSessions(start, end)
+	if view == "counts" {
+		stats = ws.sessionStats(sessions, start, end)
+	}
 	mdiv := calendar.E("div class=month")
 	mdiv.E("div class=monthname>%s", month.String())
 	mdiv.E("div class=weekday>S")
@@ -143,7 +150,9 @@ func (ws *webserver) serveCalendarMonth(calendar *htmlb.Element, year int, month
 
 			ciClass = "count"
 			for _, s := range daysess {
-				count += ws.sessionCheckInCount(s)
+				if stats[s.ID] != nil {
+					count += stats[s.ID].CheckIns
+				}
 			}
 			ciValue = strconv.Itoa(count)
 		} else {
@@ -162,28 +171,24 @@ func (ws *webserver) serveCalendarMonth(calendar *htmlb.Element, year int, month
 	}
 }
 
-// sessionCheckInCount determines the check-in count for the session, for
-// display in the calendar.
-func (ws *webserver) sessionCheckInCount(session *store.Session) int {
-	if session.ID == 0 {
-		return 0
-	}
-	rpt := report.Generate(ws.st, session)
-	return rpt.UniqueCallSigns
-}
-
 // calendarCell returns the classname and value for a calendar cell, based on
 // whether the specified call sign checked into the specified session, and
 // whether they did so with or without error.  Only the last check-in from each
 // distinct from address counts in determining whether there was error.
 func (ws *webserver) calendarCell(session *store.Session, callsign string) (class, value string) {
-	class, value = "noci", "—"
 	if session.ID == 0 {
 		return "noci", "—"
 	}
+	return checkInCell(ws.st.GetSessionMessages(session.ID), callsign)
+}
+
+// checkInCell returns the classname and value for a calendar cell, based on
+// whether the specified call sign sent any of the specified messages, and
+// whether they did so with or without error.
+func checkInCell(messages []*store.Message, callsign string) (class, value string) {
 	fromAddrs := make(map[string]int)
 	minscore := 0
-	for _, message := range ws.st.GetSessionMessages(session.ID) {
+	for _, message := range messages {
 		if message.FromCallSign != callsign {
 			continue
 		}
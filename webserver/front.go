@@ -5,7 +5,6 @@ import (
 	"time"
 
 	"github.com/rothskeller/wppsvr/htmlb"
-	"github.com/rothskeller/wppsvr/report"
 	"github.com/rothskeller/wppsvr/store"
 )
 
@@ -48,22 +47,25 @@ func (ws *webserver) serveFrontPage(w http.ResponseWriter, r *http.Request) {
 	html.E("div id=org>Santa Clara County ARES<sup>®</sup>/RACES")
 	html.E("div id=title>Weekly Packet Practice")
 	// Render the rest of the page.
-	ws.renderSessionData(html, sessions)
+	ws.renderSessionData(html, sessions, ws.sessionStats(sessions, start, end))
 	renderLoginForm(html)
 }
 
 // renderSessionData renders the sessions on the page.
-func (ws *webserver) renderSessionData(html *htmlb.Element, sessions []*store.Session) {
+func (ws *webserver) renderSessionData(html *htmlb.Element, sessions []*store.Session, stats map[int]*store.SessionStats) {
 	flow := html.E("div id=sessions")
 	for _, session := range sessions {
 		bubble := flow.E("div class=bubble")
 		bubble.E("div class=label>%s", session.Name)
 		bubble.E("div class=date>%s", session.End.Format("Monday, January 2"))
 		bubble.E("a class=instructions href=/instructions?session=%d", session.ID).R("Instructions")
-		rep := report.Generate(ws.st, session)
-		bubble.E("div class=count>%d", rep.UniqueCallSigns)
-		if rep.UniqueCallSigns != 0 {
-			bubble.E("div class=score>%d", rep.AverageValidScore)
+		var ss store.SessionStats
+		if stats[session.ID] != nil {
+			ss = *stats[session.ID]
+		}
+		bubble.E("div class=count>%d", ss.CheckIns)
+		if ss.CheckIns != 0 {
+			bubble.E("div class=score>%d", ss.AverageScore)
 		}
 		if session.Flags&store.Running != 0 {
 			bubble.E("div class=preliminary>preliminary")
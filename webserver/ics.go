@@ -0,0 +1,116 @@
+package webserver
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rothskeller/wppsvr/config"
+	"github.com/rothskeller/wppsvr/store"
+)
+
+// icsTimeFormat is the format of UTC date-times in iCalendar files.
+const icsTimeFormat = "20060102T150405Z"
+
+// icsTextEscaper escapes the characters that are special in iCalendar TEXT
+// values.
+var icsTextEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+// serveICS handles GET /sessions.ics requests.  It returns an iCalendar (RFC
+// 5545) feed of the practice sessions ending between a year ago and a year from
+// now.  One or more "series" parameters limit the feed to the sessions with the
+// specified call signs.  A "token" parameter, giving a participant's personal
+// feed token (see store.GetFeedToken), marks the sessions they checked into.
+func (ws *webserver) serveICS(w http.ResponseWriter, r *http.Request) {
+	var (
+		callsign string
+		scores   map[int][]*store.Message
+		series   = make(map[string]bool)
+		now      = time.Now()
+		start    = now.AddDate(-1, 0, 0)
+		end      = now.AddDate(1, 0, 0)
+		sb       strings.Builder
+	)
+	if token := r.FormValue("token"); token != "" {
+		if callsign = ws.st.GetFeedCallSign(token); callsign == "" {
+			http.Error(w, "404 Not Found", http.StatusNotFound)
+			return
+		}
+		scores = ws.st.GetCallSignScores(callsign, start, end)
+	}
+	for _, s := range r.Form["series"] {
+		series[strings.ToUpper(s)] = true
+	}
+	icsLine(&sb, "BEGIN:VCALENDAR")
+	icsLine(&sb, "VERSION:2.0")
+	icsLine(&sb, "PRODID:-//SCC ARES RACES//wppsvr//EN")
+	icsLine(&sb, "X-WR-CALNAME:Weekly Packet Practice")
+	for _, session := range ws.st.GetSessions(start, end) {
+		if len(series) != 0 && !series[session.CallSign] {
+			continue
+		}
+		summary := session.Name
+		if callsign != "" {
+			if class, value := checkInCell(scores[session.ID], callsign); class != "noci" {
+				summary = value + " " + summary
+			}
+		}
+		icsLine(&sb, "BEGIN:VEVENT")
+		icsLine(&sb, fmt.Sprintf("UID:%s-%s@%s", session.CallSign, session.End.UTC().Format(icsTimeFormat), serverHost()))
+		icsLine(&sb, "DTSTAMP:"+now.UTC().Format(icsTimeFormat))
+		icsLine(&sb, "DTSTART:"+session.Start.UTC().Format(icsTimeFormat))
+		icsLine(&sb, "DTEND:"+session.End.UTC().Format(icsTimeFormat))
+		icsLine(&sb, "SUMMARY:"+icsTextEscaper.Replace(summary))
+		icsLine(&sb, "DESCRIPTION:"+icsTextEscaper.Replace(icsDescription(session)))
+		if session.ID != 0 {
+			icsLine(&sb, fmt.Sprintf("URL:%s/instructions?session=%d", config.Get().ServerURL, session.ID))
+		}
+		icsLine(&sb, "END:VEVENT")
+	}
+	icsLine(&sb, "END:VCALENDAR")
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Write([]byte(sb.String()))
+}
+
+// icsDescription returns the description of a session in the iCalendar feed.
+func icsDescription(session *store.Session) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "Send to %s at %s.\n", session.CallSign, strings.Join(session.ToBBSes, " or "))
+	if len(session.DownBBSes) != 0 {
+		fmt.Fprintf(&sb, "Simulated outage: %s.\n", strings.Join(session.DownBBSes, ", "))
+	}
+	if session.ID != 0 {
+		fmt.Fprintf(&sb, "Instructions: %s/instructions?session=%d\n", config.Get().ServerURL, session.ID)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// icsLine adds a content line to an iCalendar file, folding it as needed so
+// that no line is longer than 75 octets.  (Continuation lines start with a
+// space, so they hold only 74 octets of the content line.)
+func icsLine(sb *strings.Builder, line string) {
+	for limit := 75; len(line) > limit; limit = 74 {
+		// Don't split a multibyte character.
+		cut := limit
+		for !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		sb.WriteString(line[:cut])
+		sb.WriteString("\r\n ")
+		line = line[cut:]
+	}
+	sb.WriteString(line)
+	sb.WriteString("\r\n")
+}
+
+// serverHost returns the host name of the server, for use in unique IDs.
+func serverHost() string {
+	if u, err := url.Parse(config.Get().ServerURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return "wppsvr"
+}
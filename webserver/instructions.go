@@ -97,6 +97,18 @@ func (ws *webserver) serveInstructions(w http.ResponseWriter, r *http.Request) {
 	default:
 		para.TF(" Do not use or send to %s during this session; they have simulated outages.", english.Conjoin(session.DownBBSes, "or"))
 	}
+	if len(session.Route) != 0 {
+		main.E("p").TF("This session is a relay exercise.  Your message must originate on %s and travel the route %s → %s.  The route is checked against the forwarding headers of the message as received.",
+			session.Route[0], strings.Join(session.Route, " → "), english.Conjoin(session.ToBBSes, "or"))
+	}
+	if session.ExerciseMsg != nil {
+		main.E("p").TF("This session is a two-way exercise.  When it opens, each participant on its roster is sent a %s.  Your message should be a reply to the one you received:  put its message number in the Reference field, and address it back to its sender.",
+			session.ExerciseMsg.Base().Type.Name)
+	}
+	if !session.TriggerAt.IsZero() {
+		main.E("p").TF("This session is a timed drill.  At %s, a trigger message will be sent to %s.  Do not send your message until you receive it.  Messages are scored on how quickly they arrive after the trigger message is sent.",
+			session.TriggerAt.Format("15:04 on Monday, January 2"), english.Conjoin(session.TriggerTo, "and"))
+	}
 	if session.Instructions != "" {
 		main.R(session.Instructions)
 	}
@@ -135,8 +147,5 @@ func (ws *webserver) serveInstructions(w http.ResponseWriter, r *http.Request) {
 	list.E("li>The <kbd>packet@scc-ares-races.groups.io</kbd> mailing list is the best place to ask for help or report problems.  To join that list, see the instructions on the ").
 		E("a href=https://www.scc-ares-races.org/about/email-lists target=_blank>Email Discussion Groups").
 		P().R(" page.")
-	count := ws.st.ModelImageCount(session.ID)
-	for pnum := 1; pnum <= count; pnum++ {
-		html.E("img class=modelimage src=/session/image?session=%d&page=%d", session.ID, pnum)
-	}
+	ws.emitModelImages(html, session.ID)
 }
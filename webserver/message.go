@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/rothskeller/wppsvr/analyze"
 	"github.com/rothskeller/wppsvr/htmlb"
 	"github.com/rothskeller/wppsvr/store"
 )
@@ -58,6 +59,33 @@ func (ws *webserver) serveMessage(w http.ResponseWriter, r *http.Request) {
 		lr.E("div id=msgid>%s from %s", msg.LocalID, msg.FromAddress)
 	}
 	lr.E("div id=score>Score: %d%%", msg.Score)
+	for _, resp := range ws.st.GetResponses(msg.LocalID) {
+		var receipt string
+		switch {
+		case !resp.ReadTime.IsZero():
+			receipt = "receipt read at " + resp.ReadTime.Format("2006-01-02 15:04")
+		case !resp.DeliveredTime.IsZero():
+			receipt = "receipt delivered at " + resp.DeliveredTime.Format("2006-01-02 15:04")
+		case !resp.SendTime.IsZero():
+			receipt = "receipt sent at " + resp.SendTime.Format("2006-01-02 15:04")
+		default:
+			receipt = "receipt not yet sent"
+		}
+		body.E("div class=receipt>Response %s: %s", resp.LocalID, receipt)
+	}
+	if path := analyze.ForwardingPath(msg.Message, msg.FromBBS, msg.ToBBS); len(path) > 1 {
+		// Show the route the message took through the BBS network.
+		route := body.E("div id=route")
+		for i, bbs := range path {
+			if i != 0 {
+				route.E("span class=routeArrow>→")
+			}
+			route.E("span class=routeHop>%s", bbs)
+		}
+		if session := ws.st.GetSession(msg.Session); session != nil && len(session.Route) != 0 {
+			body.E("div class=receipt>Required route: %s", strings.Join(append(session.Route, msg.ToBBS), " → "))
+		}
+	}
 	body.E("div id=rawmsg>%s", msg.Message)
 	if msg.Analysis != "" {
 		body.R(msg.Analysis)
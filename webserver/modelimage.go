@@ -3,8 +3,12 @@ package webserver
 import (
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/rothskeller/wppsvr/htmlb"
 )
 
 func (ws *webserver) serveModelImage(w http.ResponseWriter, r *http.Request) {
@@ -24,3 +28,24 @@ func (ws *webserver) serveModelImage(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Cache-Control", "nostore")
 	http.ServeContent(w, r, fh.Name(), time.Time{}, fh)
 }
+
+// emitModelImages adds the model images of the specified session to the parent
+// element, and returns the number of them.  Images rendered as PDF files are
+// embedded in a viewer rather than shown as images.
+func (ws *webserver) emitModelImages(parent *htmlb.Element, sid int) (count int) {
+	count = ws.st.ModelImageCount(sid)
+	for pnum := 1; pnum <= count; pnum++ {
+		var isPDF bool
+		if fh := ws.st.ModelImage(sid, pnum); fh != nil {
+			isPDF = strings.EqualFold(filepath.Ext(fh.Name()), ".pdf")
+			fh.Close()
+		}
+		if isPDF {
+			parent.E("object class='modelimage modelpdf' type=application/pdf data=/session/image?session=%d&page=%d", sid, pnum).
+				E("a href=/session/image?session=%d&page=%d target=_blank>View the model form (PDF)", sid, pnum)
+		} else {
+			parent.E("img class=modelimage src=/session/image?session=%d&page=%d", sid, pnum)
+		}
+	}
+	return count
+}
@@ -0,0 +1,194 @@
+package webserver
+
+import (
+	"log"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	"github.com/rothskeller/wppsvr/config"
+	"github.com/rothskeller/wppsvr/htmlb"
+	"github.com/rothskeller/wppsvr/report"
+	"github.com/rothskeller/wppsvr/store"
+)
+
+// serveProfile handles /profile requests.  It allows participants to manage
+// their preferences, such as whether and how they receive report cards.
+func (ws *webserver) serveProfile(w http.ResponseWriter, r *http.Request) {
+	var (
+		callsign   string
+		p          *store.Participant
+		emailError string
+	)
+	if callsign = ws.checkLoggedIn(w, r); callsign == "" {
+		return
+	}
+	p = ws.st.GetParticipant(callsign)
+	if r.Method == http.MethodPost {
+		var confirm bool
+
+		readReportCard(r, p)
+		readReminders(r, p)
+		emailError, confirm = readEmail(r, p)
+		if emailError == "" && confirm {
+			link := config.Get().ServerURL + "/profile/confirm?token=" + p.EmailToken
+			if err := report.SendEmailConfirmation(p.PendingEmail, callsign, link); err != nil {
+				log.Printf("ERROR: sending email confirmation to %s: %s", p.PendingEmail, err)
+				emailError = "The confirmation message could not be sent to this address."
+			}
+		}
+		if emailError == "" {
+			ws.st.SaveParticipant(p)
+			http.Redirect(w, r, "/calendar", http.StatusSeeOther)
+			return
+		}
+	}
+	// Start the HTML page.
+	w.Header().Set("Cache-Control", "nostore")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	html := htmlb.HTML(w)
+	defer html.Close()
+	html.E("meta charset=utf-8")
+	html.E("title>Weekly Packet Practice - Santa Clara County ARES/RACES")
+	html.E("meta name=viewport content='width=device-width, initial-scale=1'")
+	html.E("link rel=stylesheet href=/static/common.css")
+	html.E("link rel=stylesheet href=/static/form.css")
+	html.E("div id=org>Santa Clara County ARES<sup>®</sup>/RACES")
+	html.E("div id=title").E("a href=/>Weekly Packet Practice")
+	html.E("div id=subtitle>Profile for %s", callsign)
+	// Write the form.
+	form := html.E("form class='form form-centered' method=POST")
+	emitReportCard(form, p)
+	emitEmail(form, p, emailError != "", emailError)
+	emitReminders(form, p)
+	ws.emitFeeds(form, callsign)
+	buttons := form.E("div class=formButtons")
+	buttons.E("input type=submit class='sbtn sbtn-primary' value=Save")
+	buttons.E("button type=button class='sbtn sbtn-secondary' onclick=history.back()>Cancel")
+}
+
+func readReportCard(r *http.Request, p *store.Participant) {
+	switch rc := r.FormValue("reportCard"); rc {
+	case "monthly", "quarterly":
+		p.ReportCard = rc
+	default:
+		p.ReportCard = ""
+	}
+}
+
+func emitReportCard(form *htmlb.Element, p *store.Participant) {
+	row := form.E("div class=formRow")
+	row.E("label for=reportCardNever>Report Cards")
+	in := row.E("div class=formInput")
+	in.E("input type=radio id=reportCardNever name=reportCard value=''", p.ReportCard == "", "checked")
+	in.E("label for=reportCardNever> Don't send me report cards")
+	in.E("br")
+	in.E("input type=radio id=reportCardMonthly name=reportCard value=monthly", p.ReportCard == "monthly", "checked")
+	in.E("label for=reportCardMonthly> Send me a report card monthly")
+	in.E("br")
+	in.E("input type=radio id=reportCardQuarterly name=reportCard value=quarterly", p.ReportCard == "quarterly", "checked")
+	in.E("label for=reportCardQuarterly> Send me a report card quarterly")
+	row.E("div class=formHelp>A report card lists your check-ins, their scores, your recurring problems, and your current streak of weekly check-ins.")
+}
+
+func readReminders(r *http.Request, p *store.Participant) {
+	p.NoReminders = r.FormValue("reminders") == ""
+}
+
+func emitReminders(form *htmlb.Element, p *store.Participant) {
+	row := form.E("div class=formRow")
+	row.E("label for=reminders>Reminders")
+	in := row.E("div class=formInput")
+	in.E("input type=checkbox id=reminders name=reminders", !p.NoReminders, "checked")
+	in.E("label for=reminders> Remind me of sessions I haven’t checked into")
+	row.E("div class=formHelp>Regular participants get a BBS message shortly before a session closes if they haven’t checked into it yet.")
+}
+
+// readEmail reads the email address for report cards.  A new address isn't
+// used until it is confirmed, so it is stored as pending, with a new token for
+// the confirmation link; readEmail returns true when a confirmation message
+// needs to be sent to it.
+func readEmail(r *http.Request, p *store.Participant) (string, bool) {
+	email := strings.TrimSpace(r.FormValue("email"))
+	if email != "" {
+		addr, err := mail.ParseAddress(email)
+		if err != nil {
+			return "This is not a valid email address.", false
+		}
+		email = addr.Address
+	}
+	switch {
+	case email == "":
+		p.Email, p.PendingEmail, p.EmailToken = "", "", ""
+	case strings.EqualFold(email, p.Email):
+		p.PendingEmail, p.EmailToken = "", ""
+	case strings.EqualFold(email, p.PendingEmail):
+		break // already waiting for confirmation
+	default:
+		p.PendingEmail, p.EmailToken = email, randomToken()
+		return "", true
+	}
+	return "", false
+}
+
+func emitEmail(form *htmlb.Element, p *store.Participant, focus bool, err string) {
+	var value = p.Email
+	if p.PendingEmail != "" {
+		value = p.PendingEmail
+	}
+	row := form.E("div class=formRow")
+	row.E("label for=email>Email")
+	row.E("input type=email id=email name=email class=formInput value=%s", value, focus, "autofocus")
+	if err != "" {
+		row.E("div class=formError>%s", err)
+	}
+	if p.PendingEmail != "" {
+		row.E("div class=formHelp>A confirmation message has been sent to %s.  Report cards will be sent there once you follow the link in it.", p.PendingEmail)
+	} else {
+		row.E("div class=formHelp>If you provide an email address, report cards will be sent to it once you confirm it, using the link in a message sent there.  Otherwise, they will be sent to your BBS mailbox.")
+	}
+}
+
+// serveConfirmEmail handles GET /profile/confirm requests, from the links in
+// email address confirmation messages.  It makes the pending email address of
+// the participant with the specified token the one to which their report cards
+// are sent.  No login is needed:  following the link proves that whoever
+// controls the address agrees.
+func (ws *webserver) serveConfirmEmail(w http.ResponseWriter, r *http.Request) {
+	p := ws.st.GetParticipantByEmailToken(r.FormValue("token"))
+	if p == nil {
+		http.Error(w, "404 Not Found", http.StatusNotFound)
+		return
+	}
+	p.Email, p.PendingEmail, p.EmailToken = p.PendingEmail, "", ""
+	ws.st.SaveParticipant(p)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	html := htmlb.HTML(w)
+	defer html.Close()
+	html.E("meta charset=utf-8")
+	html.E("title>Weekly Packet Practice - Santa Clara County ARES/RACES")
+	html.E("meta name=viewport content='width=device-width, initial-scale=1'")
+	html.E("link rel=stylesheet href=/static/common.css")
+	html.E("div id=org>Santa Clara County ARES<sup>®</sup>/RACES")
+	html.E("div id=title").E("a href=/>Weekly Packet Practice")
+	html.E("div id=subtitle>Profile for %s", p.CallSign)
+	html.E("p>Report cards for %s will be sent to %s.", p.CallSign, p.Email)
+}
+
+func (ws *webserver) emitFeeds(form *htmlb.Element, callsign string) {
+	token := ws.st.GetFeedToken(callsign)
+	if token == "" {
+		token = randomToken()
+		ws.st.SaveFeedToken(callsign, token)
+	}
+	row := form.E("div class=formRow")
+	row.E("label>Calendar Feed")
+	feed := config.Get().ServerURL + "/sessions.ics?token=" + token
+	row.E("div class=formInput").E("a href=%s>%s", feed, feed)
+	row.E("div class=formHelp>Subscribe to this URL in your calendar program to see upcoming practice sessions, with the ones you checked into marked.  Add &amp;series=CALLSIGN to it to see only the sessions of one net.  Don't share it; it identifies you.")
+	row = form.E("div class=formRow")
+	row.E("label>Report Feed")
+	feed = config.Get().ServerURL + "/reports.atom?token=" + token
+	row.E("div class=formInput").E("a href=%s>%s", feed, feed)
+	row.E("div class=formHelp>Subscribe to this URL in your feed reader to receive the full report of each practice session when it closes.  Add &amp;name=NAME to it to see only the reports of one net.  Don't share it; it identifies you.")
+}
@@ -0,0 +1,51 @@
+package webserver
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/rothskeller/wppsvr/store"
+)
+
+func TestReadEmail(t *testing.T) {
+	tests := []struct {
+		name              string
+		in                string
+		email, pending    string
+		outEmail, outPend string
+		confirm, newToken bool
+	}{
+		{"new address", "kc6rsc@example.com", "", "", "", "kc6rsc@example.com", true, true},
+		{"changed address", "kc6rsc@example.net", "kc6rsc@example.com", "", "kc6rsc@example.com", "kc6rsc@example.net", true, true},
+		{"unchanged address", "KC6RSC@example.com", "kc6rsc@example.com", "old@example.com", "kc6rsc@example.com", "", false, false},
+		{"still pending", "kc6rsc@example.net", "", "kc6rsc@example.net", "", "kc6rsc@example.net", false, false},
+		{"removed address", "", "kc6rsc@example.com", "kc6rsc@example.net", "", "", false, false},
+	}
+	for _, tt := range tests {
+		p := &store.Participant{CallSign: "KC6RSC", Email: tt.email, PendingEmail: tt.pending}
+		if tt.pending != "" {
+			p.EmailToken = "old-token"
+		}
+		r := httptest.NewRequest("POST", "/profile", strings.NewReader(url.Values{"email": {tt.in}}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if err, confirm := readEmail(r, p); err != "" || confirm != tt.confirm {
+			t.Errorf("%s: readEmail = %q, %v, want \"\", %v", tt.name, err, confirm, tt.confirm)
+		}
+		if p.Email != tt.outEmail || p.PendingEmail != tt.outPend {
+			t.Errorf("%s: email = %q, pending = %q, want %q, %q", tt.name, p.Email, p.PendingEmail, tt.outEmail, tt.outPend)
+		}
+		if newToken := p.EmailToken != "" && p.EmailToken != "old-token"; newToken != tt.newToken {
+			t.Errorf("%s: new token = %v, want %v", tt.name, newToken, tt.newToken)
+		}
+		if p.PendingEmail == "" && p.EmailToken != "" {
+			t.Errorf("%s: token %q left with no pending address", tt.name, p.EmailToken)
+		}
+	}
+	r := httptest.NewRequest("POST", "/profile", strings.NewReader("email=not+an+address"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if err, _ := readEmail(r, &store.Participant{}); err == "" {
+		t.Error("readEmail accepted an invalid address")
+	}
+}
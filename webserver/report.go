@@ -1,8 +1,10 @@
 package webserver
 
 import (
+	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -11,6 +13,10 @@ import (
 	"github.com/rothskeller/wppsvr/store"
 )
 
+// serveReport handles GET /report requests.  It displays the report for a
+// session (given by the "session" parameter) or all sessions ending on a date
+// (given by the "date" parameter).  A "jurisdiction" parameter limits the
+// report to messages from that jurisdiction.
 func (ws *webserver) serveReport(w http.ResponseWriter, r *http.Request) {
 	var (
 		callsign string
@@ -38,15 +44,69 @@ func (ws *webserver) serveReport(w http.ResponseWriter, r *http.Request) {
 		io.WriteString(w, sessions[0].Report)
 		return
 	}
+	juris := strings.ToUpper(r.FormValue("jurisdiction"))
+	if len(juris) != 3 {
+		juris = ""
+	}
+	switch format := r.FormValue("format"); format {
+	case "":
+		break
+	case "csv", "json", "pdf":
+		if len(sessions) != 1 {
+			http.Error(w, "400 Bad Request: select a single session", http.StatusBadRequest)
+			return
+		}
+		var rep *report.Report
+		if juris != "" {
+			rep = report.GenerateJurisdiction(ws.st, sessions[0], juris)
+		} else {
+			rep = report.Generate(ws.st, sessions[0])
+		}
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, rep.Filename(), format))
+		switch format {
+		case "csv":
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			io.WriteString(w, rep.RenderCSV())
+		case "json":
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, rep.RenderJSON())
+		case "pdf":
+			pdf, err := rep.RenderPDF()
+			if err != nil {
+				w.Header().Del("Content-Disposition")
+				http.Error(w, "500 Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write(pdf)
+		}
+		return
+	default:
+		http.Error(w, "400 Bad Request: unknown format", http.StatusBadRequest)
+		return
+	}
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	report.RenderHTMLProlog(&sb)
 	for _, session := range sessions {
-		rep := report.Generate(ws.st, session)
+		var rep *report.Report
+		if juris != "" {
+			rep = report.GenerateJurisdiction(ws.st, session, juris)
+		} else {
+			rep = report.Generate(ws.st, session)
+		}
 		if canViewEveryone(callsign) {
+			if juris == "" {
+				rep.AddMissed(ws.st, session)
+			}
 			rep.RenderHTMLBody(&sb, "")
 		} else {
 			rep.RenderHTMLBody(&sb, callsign)
 		}
+		query := fmt.Sprintf("session=%d", session.ID)
+		if juris != "" {
+			query += "&amp;jurisdiction=" + url.QueryEscape(juris)
+		}
+		fmt.Fprintf(&sb, `<div class="downloads">Download: <a href="/report?%[1]s&amp;format=csv">CSV</a> · <a href="/report?%[1]s&amp;format=json">JSON</a> · <a href="/report?%[1]s&amp;format=pdf">PDF</a></div>`, query)
 	}
 	io.WriteString(w, sb.String())
 }
@@ -3,6 +3,7 @@ package webserver
 import (
 	"fmt"
 	"html"
+	"log"
 	"mime/multipart"
 	"net/http"
 	"net/mail"
@@ -16,9 +17,11 @@ import (
 	"github.com/rothskeller/packet/envelope"
 	"github.com/rothskeller/packet/message"
 	"github.com/rothskeller/packet/xscmsg/plaintext"
+	"github.com/rothskeller/wppsvr/analyze"
 	"github.com/rothskeller/wppsvr/config"
 	"github.com/rothskeller/wppsvr/htmlb"
 	"github.com/rothskeller/wppsvr/interval"
+	"github.com/rothskeller/wppsvr/modelgen"
 	"github.com/rothskeller/wppsvr/store"
 )
 
@@ -40,15 +43,24 @@ func (ws *webserver) serveSessionEdit(w http.ResponseWriter, r *http.Request) {
 		prefixError       string
 		reportToTextError string
 		reportToHTMLError string
+		announceToError   string
+		triggerDate       string
+		triggerTime       string
+		triggerError      string
 		bbsError          string
+		routeError        string
 		retrievalsError   string
 		mtype             string
 		msgTypesError     string
 		plainSubjectError string
 		plainBodyError    string
 		formBodyError     string
+		discardImages     bool
+		exerciseError     string
 		formImages        []*multipart.FileHeader
 		formImageError    string
+		fieldWeightsError string
+		receiptTextError  string
 	)
 	if callsign = ws.checkLoggedIn(w, r); callsign == "" {
 		return
@@ -78,25 +90,40 @@ func (ws *webserver) serveSessionEdit(w http.ResponseWriter, r *http.Request) {
 		readExcludeFromWeek(r, session)
 		reportToTextError = readReportToText(r, session)
 		reportToHTMLError = readReportToHTML(r, session)
+		readSendMissedYou(r, session)
+		announceToError = readAnnounce(r, session)
+		triggerDate, triggerTime, triggerError = readTrigger(r, session)
 		bbsError = readBBSes(r, session)
+		readCheckBBSAssignment(r, session)
+		routeError = readRoute(r, session)
 		retrievalsError = readRetrievals(r, session)
 		mtype = readMessage(r)
 		msgTypesError = readMsgTypes(r, session, mtype == "any")
 		plainSubjectError = readPlainSubject(r, session, mtype == "plain")
 		plainBodyError = readPlainBody(session, mtype == "plain")
 		formBodyError = readFormBody(r, session, mtype == "form")
-		formImages, formImageError = ws.readFormImage(r, session, mtype == "form")
+		if r.FormValue("generate") != "" && generateModel(r, session) {
+			mtype, formBodyError = "form", ""
+		}
+		formImages, discardImages, formImageError = ws.readFormImage(r, session, mtype == "form")
+		fieldWeightsError = readFieldWeights(r, session, mtype != "any")
+		exerciseError = readExercise(r, session, mtype)
 		readInstructions(r, session)
-		if startError == "" && endError == "" && nameError == "" && callSignError == "" &&
-			prefixError == "" && reportToTextError == "" && reportToHTMLError == "" && bbsError == "" &&
-			retrievalsError == "" && msgTypesError == "" && plainSubjectError == "" && plainBodyError == "" &&
-			formBodyError == "" && formImageError == "" {
+		receiptTextError = readReceiptText(r, session)
+		// A "generate" request shows the generated model message in the
+		// editor without saving it.
+		if r.FormValue("generate") == "" && startError == "" && endError == "" && nameError == "" && callSignError == "" &&
+			prefixError == "" && reportToTextError == "" && reportToHTMLError == "" && announceToError == "" && triggerError == "" && bbsError == "" &&
+			routeError == "" && retrievalsError == "" && msgTypesError == "" && plainSubjectError == "" && plainBodyError == "" &&
+			formBodyError == "" && formImageError == "" && fieldWeightsError == "" && exerciseError == "" &&
+			receiptTextError == "" {
 			var copyImagesFromSession int
 			if r.FormValue("copy") != "" {
 				copyImagesFromSession = session.ID
 				session.ID = 0
 				session.Flags &^= store.Modified | store.Running | store.Imported
 				session.Report = ""
+				session.TriggerSent = time.Time{}
 				ws.st.CreateSession(session)
 			} else {
 				if session.Flags&store.Running != 0 {
@@ -106,21 +133,31 @@ func (ws *webserver) serveSessionEdit(w http.ResponseWriter, r *http.Request) {
 			}
 			if mtype != "form" {
 				ws.st.DeleteModelImages(session.ID)
-			} else if len(formImages) != 0 {
-				ws.st.DeleteModelImages(session.ID)
-				for i, fh := range formImages {
-					body, err := fh.Open()
-					if err == nil {
-						ws.st.SaveModelImage(session.ID, i+1, fh.Filename, body)
-						body.Close()
-					}
+			} else {
+				// The model message is rendered on every save, so that
+				// the rendering always matches it.  Uploaded images,
+				// if any, override the rendering.
+				if err := ws.st.RenderModelImage(session.ID, session.ModelMsg); err != nil {
+					log.Printf("ERROR: rendering model image for session %d: %s", session.ID, err)
 				}
-			} else if r.FormValue("copy") != "" {
-				count := ws.st.ModelImageCount(copyImagesFromSession)
-				for pnum := 1; pnum <= count; pnum++ {
-					if body := ws.st.ModelImage(copyImagesFromSession, pnum); body != nil {
-						ws.st.SaveModelImage(session.ID, pnum, body.Name(), body)
-						body.Close()
+				if len(formImages) != 0 {
+					ws.st.DeleteUploadedModelImages(session.ID)
+					for i, fh := range formImages {
+						body, err := fh.Open()
+						if err == nil {
+							ws.st.SaveModelImage(session.ID, i+1, fh.Filename, body)
+							body.Close()
+						}
+					}
+				} else if discardImages {
+					ws.st.DeleteUploadedModelImages(session.ID)
+				} else if r.FormValue("copy") != "" && ws.st.ModelImagesUploaded(copyImagesFromSession) {
+					count := ws.st.ModelImageCount(copyImagesFromSession)
+					for pnum := 1; pnum <= count; pnum++ {
+						if body := ws.st.ModelImage(copyImagesFromSession, pnum); body != nil {
+							ws.st.SaveModelImage(session.ID, pnum, body.Name(), body)
+							body.Close()
+						}
 					}
 				}
 			}
@@ -134,6 +171,9 @@ func (ws *webserver) serveSessionEdit(w http.ResponseWriter, r *http.Request) {
 		if !session.End.IsZero() {
 			endDate, endTime = session.End.Format("2006-01-02"), session.End.Format("15:04")
 		}
+		if !session.TriggerAt.IsZero() {
+			triggerDate, triggerTime = session.TriggerAt.Format("2006-01-02"), session.TriggerAt.Format("15:04")
+		}
 		switch session.ModelMsg.(type) {
 		case nil:
 			mtype = "any"
@@ -168,15 +208,24 @@ func (ws *webserver) serveSessionEdit(w http.ResponseWriter, r *http.Request) {
 	emitExcludeFromWeek(form, session)
 	emitReportToText(form, session, reportToTextError != "", reportToTextError)
 	emitReportToHTML(form, session, reportToHTMLError != "", reportToHTMLError)
+	emitSendMissedYou(form, session)
+	ws.emitAnnounce(form, session, announceToError != "", announceToError)
+	emitTrigger(form, session, triggerDate, triggerTime, triggerError != "", triggerError)
 	emitBBSes(form, session, bbsError != "", bbsError)
+	emitCheckBBSAssignment(form, session)
+	emitRoute(form, session, routeError != "", routeError)
 	emitRetrievals(form, session, retrievalsError != "", retrievalsError)
 	emitMessage(form, mtype)
 	emitMsgTypes(form, session, mtype == "any", msgTypesError != "", msgTypesError)
 	emitPlainSubject(form, session, mtype == "plain", plainSubjectError != "", plainSubjectError)
 	emitPlainBody(form, session, mtype == "plain", plainBodyError != "", plainBodyError)
 	emitFormBody(form, session, mtype == "form", formBodyError != "", formBodyError)
+	emitGenerate(form, mtype == "form")
 	ws.emitFormImage(form, session, mtype == "form", formImageError != "", formImageError)
+	emitFieldWeights(form, session, mtype != "any", fieldWeightsError != "", fieldWeightsError)
+	ws.emitExercise(form, session, exerciseError != "", exerciseError)
 	emitInstructions(form, session)
+	emitReceiptText(form, session, receiptTextError != "", receiptTextError)
 	emitButtons(form, session)
 }
 
@@ -372,6 +421,127 @@ func emitReportToHTML(form *htmlb.Element, session *store.Session, focus bool, e
 	row.E("div class=formHelp>Email addresses to which the HTML-formatted session report should be sent (one per line).")
 }
 
+func readSendMissedYou(r *http.Request, session *store.Session) {
+	if r.FormValue("missedYou") != "" {
+		session.Flags |= store.SendMissedYou
+	} else {
+		session.Flags &^= store.SendMissedYou
+	}
+}
+
+func emitSendMissedYou(form *htmlb.Element, session *store.Session) {
+	row := form.E("div class='formRow missedYou'")
+	row.E("label for=missedYou>Send “Missed You”")
+	row.E("div class=formInput").
+		E("input type=checkbox id=missedYou name=missedYou", session.Flags&store.SendMissedYou != 0, "checked")
+	row.E("div class=formHelp>When the session closes, send a friendly message to regular participants who didn’t check in.")
+}
+
+func readAnnounce(r *http.Request, session *store.Session) (err string) {
+	session.AnnounceTo = strings.Fields(r.FormValue("announceTo"))
+	for _, addr := range session.AnnounceTo {
+		if _, bad := mail.ParseAddress(addr); bad != nil {
+			err = "“" + html.EscapeString(addr) + "” is not a valid packet address."
+		}
+	}
+	session.AnnounceText = strings.TrimSpace(removeCR.Replace(r.FormValue("announceText")))
+	return err
+}
+
+func (ws *webserver) emitAnnounce(form *htmlb.Element, session *store.Session, focus bool, err string) {
+	row := form.E("div class='formRow announceTo'")
+	row.E("label for=announceTo>Announce To")
+	row.E("textarea id=announceTo name=announceTo class=formInput", focus, "autofocus").R(strings.Join(session.AnnounceTo, "\n"))
+	if err != "" {
+		row.E("div class=formError>%s", err)
+	}
+	row.E("div class=formHelp>Packet addresses (including bulletin addresses) to which an announcement should be posted when the session opens (one per line).  Announcements that can’t be posted are retried while the session is running.")
+	row = form.E("div class=formRow")
+	row.E("label for=announceText>Announcement")
+	row.E("textarea id=announceText name=announceText rows=3 class=formInput").T(session.AnnounceText)
+	row.E("div class=formHelp>Text added to the announcement, after the generated description of the session.")
+	if session.ID == 0 {
+		return
+	}
+	if list := ws.st.GetAnnouncements(session.ID); len(list) != 0 {
+		row = form.E("div class=formRow")
+		row.E("label>Announced")
+		in := row.E("div class=formInput")
+		for _, a := range list {
+			if a.Error == "" {
+				in.E("div>Sent to %s at %s", a.To, a.Sent.Format("2006-01-02 15:04"))
+			} else {
+				in.E("div class=formError>Not sent to %s at %s: %s", a.To, a.Sent.Format("2006-01-02 15:04"), a.Error)
+			}
+		}
+	}
+}
+
+func readTrigger(r *http.Request, session *store.Session) (datestr, timestr, err string) {
+	var at time.Time
+
+	datestr, timestr = r.FormValue("triggerDate"), r.FormValue("triggerTime")
+	session.TriggerTo = strings.Fields(r.FormValue("triggerTo"))
+	session.TriggerText = strings.TrimSpace(removeCR.Replace(r.FormValue("triggerText")))
+	if datestr == "" && timestr == "" {
+		session.TriggerAt, session.TriggerSent = time.Time{}, time.Time{}
+		return datestr, timestr, ""
+	}
+	if datestr == "" || timestr == "" {
+		return datestr, timestr, "The trigger date and time must both be given, or both be empty."
+	}
+	if _, err := time.Parse("2006-01-02", datestr); err != nil {
+		return datestr, timestr, "The trigger date is not a valid YYYY-MM-DD date."
+	}
+	if _, err := time.Parse("15:04", timestr); err != nil {
+		return datestr, timestr, "The trigger time is not a valid HH:MM time."
+	}
+	at, _ = time.ParseInLocation("2006-01-02 15:04", datestr+" "+timestr, time.Local)
+	if !at.Equal(session.TriggerAt) && at.After(time.Now()) {
+		// The trigger has been rescheduled, so it needs to be sent
+		// (again).
+		session.TriggerSent = time.Time{}
+	}
+	session.TriggerAt = at
+	if !session.Start.IsZero() && !session.End.IsZero() && (at.Before(session.Start) || !at.Before(session.End)) {
+		return datestr, timestr, "The trigger time must be during the session."
+	}
+	if len(session.TriggerTo) == 0 {
+		return datestr, timestr, "At least one address to send the trigger to is required."
+	}
+	for _, addr := range session.TriggerTo {
+		if _, bad := mail.ParseAddress(addr); bad != nil {
+			return datestr, timestr, "“" + html.EscapeString(addr) + "” is not a valid packet address."
+		}
+	}
+	return datestr, timestr, ""
+}
+
+func emitTrigger(form *htmlb.Element, session *store.Session, date, time string, focus bool, err string) {
+	row := form.E("div class='formRow sessionTrigger'")
+	row.E("label for=triggerDate>Drill Trigger at")
+	dt := row.E("div class='formInput formRange'")
+	dt.E("input type=date id=triggerDate name=triggerDate value=%s", date, focus, "autofocus")
+	dt.E("input type=time id=triggerTime name=triggerTime value=%s step=300", time)
+	if err != "" {
+		row.E("div class=formError>%s", err)
+	}
+	row.E("div class=formHelp>For a timed drill, the date and time when the trigger message is sent.  Check-ins are scored by how quickly they arrive after it.  Leave empty for a regular session.")
+	row = form.E("div class='formRow triggerTo'")
+	row.E("label for=triggerTo>Send Trigger To")
+	row.E("textarea id=triggerTo name=triggerTo class=formInput").R(strings.Join(session.TriggerTo, "\n"))
+	row.E("div class=formHelp>Packet addresses (including bulletin addresses) to which the drill trigger message should be posted (one per line).")
+	row = form.E("div class=formRow")
+	row.E("label for=triggerText>Trigger Message")
+	row.E("textarea id=triggerText name=triggerText rows=3 class=formInput").T(session.TriggerText)
+	row.E("div class=formHelp>Text of the drill trigger message, before the generated instructions for responding.")
+	if !session.TriggerSent.IsZero() {
+		row = form.E("div class=formRow")
+		row.E("label>Trigger Sent")
+		row.E("div class=formInput>%s", session.TriggerSent.Format("2006-01-02 15:04:05"))
+	}
+}
+
 func readBBSes(r *http.Request, session *store.Session) string {
 	session.ToBBSes, session.DownBBSes = session.ToBBSes[:0], session.DownBBSes[:0]
 	bbsnames := make([]string, 0, len(config.Get().BBSes))
@@ -436,6 +606,45 @@ func emitBBSes(form *htmlb.Element, session *store.Session, focus bool, err stri
 	}
 }
 
+func readCheckBBSAssignment(r *http.Request, session *store.Session) {
+	if r.FormValue("checkAssignment") != "" {
+		session.Flags |= store.CheckBBSAssignment
+	} else {
+		session.Flags &^= store.CheckBBSAssignment
+	}
+}
+
+func emitCheckBBSAssignment(form *htmlb.Element, session *store.Session) {
+	row := form.E("div class='formRow checkAssignment'")
+	row.E("label for=checkAssignment>Check BBS Assignment")
+	row.E("div class=formInput").
+		E("input type=checkbox id=checkAssignment name=checkAssignment", session.Flags&store.CheckBBSAssignment != 0, "checked")
+	row.E("div class=formHelp>Require messages from county BBSes to be sent from the BBS assigned to the sender’s jurisdiction.")
+}
+
+func readRoute(r *http.Request, session *store.Session) string {
+	session.Route = strings.Fields(strings.ToUpper(r.FormValue("route")))
+	for _, name := range session.Route {
+		if config.Get().BBSes[name] == nil {
+			return fmt.Sprintf("%q is not a known BBS.", name)
+		}
+		if slices.Contains(session.ToBBSes, name) {
+			return fmt.Sprintf("%s is a destination BBS; the route should list only the BBSes before it.", name)
+		}
+	}
+	return ""
+}
+
+func emitRoute(form *htmlb.Element, session *store.Session, focus bool, err string) {
+	row := form.E("div class=formRow")
+	row.E("label for=route>Required Route")
+	row.E("input id=route name=route value=%s", strings.Join(session.Route, " "), focus, "autofocus")
+	if err != "" {
+		row.E("div class=formError>%s", err)
+	}
+	row.E("div class=formHelp>For a relay exercise, the BBSes (separated by spaces) that messages must pass through on their way to a destination BBS, starting with the one they must originate on.  This is verified from the forwarding headers of received messages.  Leave empty for a regular session.")
+}
+
 func readRetrievals(r *http.Request, session *store.Session) string {
 	if r.FormValue("dontKillMessages") != "" {
 		session.Flags |= store.DontKillMessages
@@ -608,11 +817,12 @@ func readFormBody(r *http.Request, session *store.Session, show bool) string {
 		return ""
 	}
 	body := strings.TrimSpace(removeCR.Replace(r.FormValue("formBody")))
-	session.ModelMessage = "Subject: \n\n" + body
+	session.ModelMessage, session.ModelMsg = "Subject: \n\n"+body, nil
 	if body == "" {
 		return "The encoded form body is required."
 	}
 	form := message.Decode(new(envelope.Envelope), body)
+	session.ModelMsg = form
 	if _, ok := form.(*plaintext.PlainText); ok {
 		return "This is not a valid PackItForms-encoded form."
 	}
@@ -637,14 +847,48 @@ func emitFormBody(form *htmlb.Element, session *store.Session, show, focus bool,
 	row.E("div class=formHelp>PackItForms encoding of the expected form.  Note that the message number and the operator-only fields will be ignored.  Handling and destination fields can be left blank to require the sender to provide a correct value from the recommended routing cheat sheet.")
 }
 
-func (ws *webserver) readFormImage(r *http.Request, session *store.Session, show bool) ([]*multipart.FileHeader, string) {
+// generateModel replaces the session's model message with a generated one of
+// the requested form type.  It returns whether it did so.
+func generateModel(r *http.Request, session *store.Session) bool {
+	msg := modelgen.Generate(r.FormValue("generateType"), session)
+	if msg == nil {
+		return false
+	}
+	session.ModelMessage = "Subject: \n\n" + msg.EncodeBody()
+	session.ModelMsg = msg
+	return true
+}
+
+func emitGenerate(form *htmlb.Element, show bool) {
+	types := modelgen.Types()
+	if len(types) == 0 {
+		return
+	}
+	row := form.E("div id=formGenerateRow class=formRow", !show, "style=display:none")
+	row.E("label for=generateType>Generate Form")
+	in := row.E("div class=formInput")
+	sel := in.E("select id=generateType name=generateType")
+	for _, tag := range types {
+		sel.E("option value=%s>%s", tag, message.RegisteredTypes[tag][0].Name)
+	}
+	// This isn't a submit button, so that pressing Enter in the form still
+	// saves it.
+	in.E("input type=hidden name=generate")
+	in.E("button type=button class='sbtn sbtn-secondary' onclick='this.form.generate.value=1; this.form.submit()'>Generate")
+	row.E("div class=formHelp>Replaces the encoded form above with a randomly generated one of the selected type.")
+}
+
+func (ws *webserver) readFormImage(r *http.Request, session *store.Session, show bool) (files []*multipart.FileHeader, discard bool, err string) {
 	if !show {
-		return nil, ""
+		return nil, false, ""
 	}
-	if ws.st.ModelImageCount(session.ID) == 0 && len(r.MultipartForm.File["formImage"]) == 0 {
-		return nil, "At least one form image is required."
+	files = r.MultipartForm.File["formImage"]
+	uploaded := ws.st.ModelImagesUploaded(session.ID)
+	discard = uploaded && r.FormValue("discardImages") != ""
+	if len(files) == 0 && (discard || !uploaded) && (session.ModelMsg == nil || !session.ModelMsg.PDFRenderable()) {
+		return files, discard, "This form can't be rendered automatically, so at least one form image is required."
 	}
-	return r.MultipartForm.File["formImage"], ""
+	return files, discard, ""
 }
 
 func (ws *webserver) emitFormImage(form *htmlb.Element, session *store.Session, show, focus bool, err string) {
@@ -653,18 +897,126 @@ func (ws *webserver) emitFormImage(form *htmlb.Element, session *store.Session,
 	row.E("label for=formImage", count != 0, "class=imagelabel").R("Form Image(s)")
 	in := row.E("div class=formInput")
 	if count != 0 {
-		for pnum := 1; pnum <= count; pnum++ {
-			in.E("img class=modelimage src=/session/image?session=%d&page=%d", session.ID, pnum)
-		}
+		ws.emitModelImages(in, session.ID)
 		in.E("br")
 	} else {
 		in.E("div>No form images on file yet.")
 	}
-	in.E("input type=file id=formImage name=formImage accept=image/*,.jpg,.jpeg,.png capture=environment multiple", focus, "autofocus")
+	in.E("input type=file id=formImage name=formImage accept=image/*,.jpg,.jpeg,.png,.pdf capture=environment multiple", focus, "autofocus")
+	if ws.st.ModelImagesUploaded(session.ID) {
+		in.E("br")
+		in.E("input type=checkbox id=discardImages name=discardImages")
+		in.E("label for=discardImages> Discard uploaded images and use the rendered form")
+	}
+	if err != "" {
+		row.E("div class=formError>%s", err)
+	}
+	row.E("div class=formHelp>Image(s) of the expected form.  This is what operators will be shown to tell them what to send as a practice message.  When the session is saved, the encoded form is rendered as a PDF and used as the image, unless images are uploaded here.")
+}
+
+func readFieldWeights(r *http.Request, session *store.Session, show bool) string {
+	session.FieldWeights = nil
+	if !show {
+		return ""
+	}
+	for _, line := range strings.Split(removeCR.Replace(r.FormValue("fieldWeights")), "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		idx := strings.LastIndexByte(line, '=')
+		if idx < 0 {
+			return fmt.Sprintf("%q is not in the form “Field Name = weight”.", line)
+		}
+		label := strings.TrimSpace(line[:idx])
+		weight, err := strconv.Atoi(strings.TrimSpace(line[idx+1:]))
+		if label == "" || strings.Contains(label, ";") || err != nil || weight < 0 {
+			return fmt.Sprintf("%q is not in the form “Field Name = weight”.", line)
+		}
+		if session.FieldWeights == nil {
+			session.FieldWeights = make(map[string]int)
+		}
+		session.FieldWeights[label] = weight
+	}
+	return ""
+}
+
+func emitFieldWeights(form *htmlb.Element, session *store.Session, show, focus bool, err string) {
+	var lines []string
+
+	for label, weight := range session.FieldWeights {
+		lines = append(lines, fmt.Sprintf("%s = %d", label, weight))
+	}
+	sort.Strings(lines)
+	row := form.E("div id=fieldWeightsRow class=formRow", !show, "style=display:none")
+	row.E("label for=fieldWeights>Field Weights")
+	row.E("textarea id=fieldWeights name=fieldWeights rows=4 class=formInput", focus, "autofocus").T(strings.Join(lines, "\n"))
+	if err != "" {
+		row.E("div class=formError>%s", err)
+	}
+	row.E("div class=formHelp>Relative weights of fields when comparing received messages against the model, one “Field Name = weight” per line.  Fields not listed have weight 1; weight 0 ignores the field.")
+}
+
+func readExercise(r *http.Request, session *store.Session, mtype string) string {
+	body := strings.TrimSpace(removeCR.Replace(r.FormValue("exercise")))
+	session.ExerciseTo = strings.Fields(r.FormValue("exerciseTo"))
+	session.Exercise = ""
+	if body == "" {
+		return ""
+	}
+	session.Exercise = "Subject: \n\n" + body
+	form := message.Decode(new(envelope.Envelope), body)
+	if _, ok := form.(*plaintext.PlainText); ok {
+		return "This is not a valid PackItForms-encoded form."
+	}
+	if len(form.Base().UnknownFields) != 0 {
+		return "This form contains fields that are not valid for its type and version."
+	}
+	if mtype != "any" {
+		return "A two-way exercise needs the accepted reply message type(s), not a model message."
+	}
+	if len(session.ExerciseTo) == 0 {
+		return "At least one address to send the exercise message to is required."
+	}
+	for _, addr := range session.ExerciseTo {
+		if _, bad := mail.ParseAddress(addr); bad != nil {
+			return "“" + html.EscapeString(addr) + "” is not a valid packet address."
+		}
+	}
+	return ""
+}
+
+func (ws *webserver) emitExercise(form *htmlb.Element, session *store.Session, focus bool, err string) {
+	var body string
+
+	if strings.HasPrefix(session.Exercise, "Subject: ") {
+		_, body, _ = strings.Cut(session.Exercise[9:], "\n\n")
+	}
+	row := form.E("div class=formRow")
+	row.E("label for=exercise>Exercise Message")
+	row.E("textarea id=exercise name=exercise rows=4 class=formInput", focus, "autofocus").T(body)
 	if err != "" {
 		row.E("div class=formError>%s", err)
 	}
-	row.E("div class=formHelp>Image(s) of the expected form.  This is what operators will be shown to tell them what to send as a practice message.")
+	row.E("div class=formHelp>For a two-way exercise, the PackItForms encoding of the form sent to each participant on the exercise roster when the session opens.  Each copy gets its own message number.  Participants must reply with a form of an accepted message type, referencing that message number and addressed to the exercise message’s sender.  Leave empty for a regular session.")
+	row = form.E("div class=formRow")
+	row.E("label for=exerciseTo>Exercise Roster")
+	row.E("textarea id=exerciseTo name=exerciseTo class=formInput").R(strings.Join(session.ExerciseTo, "\n"))
+	row.E("div class=formHelp>Packet addresses of the participants to whom the exercise message should be sent (one per line).  Copies that can’t be sent are retried while the session is running.")
+	if session.ID == 0 {
+		return
+	}
+	if list := ws.st.GetExerciseMessages(session.ID); len(list) != 0 {
+		row = form.E("div class=formRow")
+		row.E("label>Exercise Sent")
+		in := row.E("div class=formInput")
+		for _, em := range list {
+			if em.Error == "" {
+				in.E("div>%s to %s at %s", em.MessageID, em.CallSign, em.Sent.Format("2006-01-02 15:04"))
+			} else {
+				in.E("div class=formError>Not sent to %s at %s: %s", em.CallSign, em.Sent.Format("2006-01-02 15:04"), em.Error)
+			}
+		}
+	}
 }
 
 func readInstructions(r *http.Request, session *store.Session) {
@@ -678,6 +1030,27 @@ func emitInstructions(form *htmlb.Element, session *store.Session) {
 	row.E("div class=formHelp>HTML-encoded additional instructions for practice message senders.")
 }
 
+func readReceiptText(r *http.Request, session *store.Session) string {
+	session.ReceiptText = strings.TrimSpace(removeCR.Replace(r.FormValue("receiptText")))
+	if session.ReceiptText == "" {
+		return ""
+	}
+	if err := analyze.CheckReceiptText(session.ReceiptText); err != nil {
+		return fmt.Sprintf("The receipt text is not a valid template: %s", err)
+	}
+	return ""
+}
+
+func emitReceiptText(form *htmlb.Element, session *store.Session, focus bool, err string) {
+	row := form.E("div class=formRow")
+	row.E("label for=receiptText>Receipt Text")
+	row.E("textarea id=receiptText name=receiptText rows=3 class=formInput", focus, "autofocus").T(session.ReceiptText)
+	if err != "" {
+		row.E("div class=formError>%s", err)
+	}
+	row.E("div class=formHelp>Text added to delivery receipts, replacing the configured text.  It may use {{.Score}}, {{.Summary}}, {{.SessionName}}, {{.SessionDate}}, {{.ServerURL}}, and {{.MessageLink}}.")
+}
+
 func emitButtons(form *htmlb.Element, session *store.Session) {
 	buttons := form.E("div class=formButtons")
 	buttons.E("input type=submit name=save class='sbtn sbtn-primary' value=Save", session.Report != "", "class=sbtn-disabled disabled")
@@ -28,12 +28,15 @@ window.addEventListener('load', function () {
     })
   })
 
+  let generateRow = document.getElementById('formGenerateRow')
   document.getElementById('anyMessage').addEventListener('click', function () {
     document.getElementById('mtypeRow').style.display = null
     document.getElementById('plainSubjectRow').style.display = 'none'
     document.getElementById('plainBodyRow').style.display = 'none'
     document.getElementById('formBodyRow').style.display = 'none'
     document.getElementById('formImageRow').style.display = 'none'
+    document.getElementById('fieldWeightsRow').style.display = 'none'
+    if (generateRow) generateRow.style.display = 'none'
   })
   document.getElementById('plainMessage').addEventListener('click', function () {
     document.getElementById('mtypeRow').style.display = 'none'
@@ -41,6 +44,8 @@ window.addEventListener('load', function () {
     document.getElementById('plainBodyRow').style.display = null
     document.getElementById('formBodyRow').style.display = 'none'
     document.getElementById('formImageRow').style.display = 'none'
+    document.getElementById('fieldWeightsRow').style.display = null
+    if (generateRow) generateRow.style.display = 'none'
   })
   document.getElementById('formMessage').addEventListener('click', function () {
     document.getElementById('mtypeRow').style.display = 'none'
@@ -48,5 +53,7 @@ window.addEventListener('load', function () {
     document.getElementById('plainBodyRow').style.display = 'none'
     document.getElementById('formBodyRow').style.display = null
     document.getElementById('formImageRow').style.display = null
+    document.getElementById('fieldWeightsRow').style.display = null
+    if (generateRow) generateRow.style.display = null
   })
 })
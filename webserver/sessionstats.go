@@ -0,0 +1,27 @@
+package webserver
+
+import (
+	"time"
+
+	"github.com/rothskeller/wppsvr/report"
+	"github.com/rothskeller/wppsvr/store"
+)
+
+// sessionStats returns the statistics for the supplied sessions, which must be
+// the sessions that end during the specified time range.  The returned map is
+// keyed by session ID.  The statistics come from the database cache where they
+// are current; otherwise they are computed from the session report and saved
+// to the cache.
+func (ws *webserver) sessionStats(sessions []*store.Session, start, end time.Time) (stats map[int]*store.SessionStats) {
+	stats = ws.st.GetSessionStats(start, end)
+	for _, session := range sessions {
+		if session.ID == 0 || stats[session.ID] != nil {
+			continue
+		}
+		version := ws.st.SessionVersion(session.ID)
+		rpt := report.Cached(ws.st, session)
+		stats[session.ID] = &store.SessionStats{CheckIns: rpt.UniqueCallSigns, AverageScore: rpt.AverageValidScore}
+		ws.st.SaveSessionStats(session.ID, version, stats[session.ID])
+	}
+	return stats
+}
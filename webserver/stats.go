@@ -0,0 +1,183 @@
+package webserver
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/rothskeller/wppsvr/htmlb"
+	"github.com/rothskeller/wppsvr/report"
+	"github.com/rothskeller/wppsvr/store"
+)
+
+// problemStats contains the monthly counts of a single problem over a year.
+type problemStats struct {
+	name   string
+	counts [12]int
+	total  int
+}
+
+// serveStats handles GET /stats requests.  It displays a table of how often
+// each problem was seen in each month of the year, with an indication of
+// whether each problem is becoming more or less common.
+func (ws *webserver) serveStats(w http.ResponseWriter, r *http.Request) {
+	var (
+		year     = time.Now().Year()
+		messages [12]int
+		problems = make(map[string]*problemStats)
+		list     []*problemStats
+	)
+	if callsign := ws.checkLoggedIn(w, r); callsign == "" {
+		return
+	}
+	if y, err := strconv.Atoi(r.FormValue("year")); err == nil && y > 2000 && y < 3000 {
+		year = y
+	}
+	// Gather the statistics.  The session reports count problems only on
+	// the last message from each address, so the message counts used for
+	// the rates must do the same:  they are the valid and invalid counts,
+	// which exclude replaced messages.
+	for _, session := range ws.st.GetSessions(
+		time.Date(year, 1, 1, 0, 0, 0, 0, time.Local),
+		time.Date(year+1, 1, 1, 0, 0, 0, 0, time.Local),
+	) {
+		if session.ID == 0 || session.Flags&store.Imported != 0 {
+			continue
+		}
+		month := session.End.Month() - time.January
+		rpt := report.Cached(ws.st, session)
+		messages[month] += rpt.ValidCount + rpt.InvalidCount
+		for _, p := range rpt.Problems {
+			ps := problems[p.Name]
+			if ps == nil {
+				ps = &problemStats{name: p.Name}
+				problems[p.Name] = ps
+			}
+			ps.counts[month] += p.Count
+			ps.total += p.Count
+		}
+	}
+	for _, ps := range problems {
+		list = append(list, ps)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].total != list[j].total {
+			return list[i].total > list[j].total
+		}
+		return list[i].name < list[j].name
+	})
+	// Start the HTML page.
+	w.Header().Set("Cache-Control", "nostore")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	html := htmlb.HTML(w)
+	defer html.Close()
+	html.E("meta charset=utf-8")
+	html.E("title>Weekly Packet Practice - Santa Clara County ARES/RACES")
+	html.E("meta name=viewport content='width=device-width, initial-scale=1'")
+	html.E("link rel=stylesheet href=/static/common.css")
+	html.E("link rel=stylesheet href=/static/stats.css")
+	html.E("div id=org>Santa Clara County ARES<sup>®</sup>/RACES")
+	html.E("div id=title").E("a href=/>Weekly Packet Practice")
+	html.E("div id=subtitle>Problem Statistics")
+	// Write the year selector.
+	yearsel := html.E("div id=year")
+	lastyear := yearsel.E("div id=lastyear")
+	if ws.yearHasSessions(year - 1) {
+		lastyear = lastyear.E("a href=?year=%d", year-1)
+	}
+	lastyear.TF("< %d", year-1)
+	yearsel.E("div id=thisyear>%d", year)
+	nextyear := yearsel.E("div id=nextyear")
+	if ws.yearHasSessions(year + 1) {
+		nextyear = nextyear.E("a href=?year=%d", year+1)
+	}
+	nextyear.TF("%d >", year+1)
+	if len(list) == 0 {
+		html.E("div id=none>No problems were found in messages sent during %d.", year)
+		return
+	}
+	// Write the table.
+	table := html.E("table id=stats")
+	tr := table.E("tr")
+	tr.E("th>Problem")
+	for month := time.January; month <= time.December; month++ {
+		tr.E("th>%s", month.String()[:3])
+	}
+	tr.E("th>Total")
+	tr.E("th>Trend")
+	tr = table.E("tr class=messages")
+	tr.E("td>Messages")
+	var total int
+	for _, count := range messages {
+		if count != 0 {
+			tr.E("td>%d", count)
+		} else {
+			tr.E("td")
+		}
+		total += count
+	}
+	tr.E("td>%d", total)
+	tr.E("td")
+	for _, ps := range list {
+		tr = table.E("tr")
+		tr.E("td>%s", ps.name)
+		for month, count := range ps.counts {
+			if count != 0 {
+				tr.E("td title='%d%% of messages'>%d", count*100/messages[month], count)
+			} else if messages[month] != 0 {
+				tr.E("td class=zero>0")
+			} else {
+				tr.E("td")
+			}
+		}
+		tr.E("td>%d", ps.total)
+		switch problemTrend(ps.counts, messages) {
+		case 1:
+			tr.E("td class=up title='more common recently'>▲")
+		case -1:
+			tr.E("td class=down title='less common recently'>▼")
+		default:
+			tr.E("td class=flat>–")
+		}
+	}
+	html.E("div id=hint>Counts are numbers of messages with each problem.  The trend compares the rate of each problem over the last three months with messages against the rate earlier in the year.")
+}
+
+// problemTrend returns 1 if the problem has become more common in the most
+// recent three months (with messages) of the year, -1 if it has become less
+// common, or 0 if there is no significant change or not enough data to tell.
+func problemTrend(counts, messages [12]int) int {
+	var (
+		recentCount, recentMessages int
+		earlyCount, earlyMessages   int
+		seen                        int
+	)
+	for month := 11; month >= 0; month-- {
+		if messages[month] == 0 {
+			continue
+		}
+		if seen < 3 {
+			recentCount += counts[month]
+			recentMessages += messages[month]
+		} else {
+			earlyCount += counts[month]
+			earlyMessages += messages[month]
+		}
+		seen++
+	}
+	if recentMessages == 0 || earlyMessages == 0 {
+		return 0
+	}
+	// Compare the rates in percent, ignoring differences of less than
+	// a quarter of the earlier rate or less than one percentage point.
+	recent := float64(recentCount) * 100 / float64(recentMessages)
+	early := float64(earlyCount) * 100 / float64(earlyMessages)
+	switch {
+	case recent-early >= 1 && recent > early*1.25:
+		return 1
+	case early-recent >= 1 && recent < early*0.75:
+		return -1
+	}
+	return 0
+}
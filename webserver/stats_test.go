@@ -0,0 +1,44 @@
+package webserver
+
+import "testing"
+
+func TestProblemTrend(t *testing.T) {
+	tests := []struct {
+		name     string
+		counts   [12]int
+		messages [12]int
+		want     int
+	}{
+		{"no data", [12]int{}, [12]int{}, 0},
+		{"only recent months",
+			[12]int{0, 0, 0, 0, 0, 0, 0, 0, 0, 5, 5, 5},
+			[12]int{0, 0, 0, 0, 0, 0, 0, 0, 0, 50, 50, 50}, 0},
+		{"steady",
+			[12]int{5, 5, 5, 5, 5, 5},
+			[12]int{50, 50, 50, 50, 50, 50}, 0},
+		{"rising",
+			[12]int{2, 2, 2, 6, 6, 6},
+			[12]int{50, 50, 50, 50, 50, 50}, 1},
+		{"falling",
+			[12]int{6, 6, 6, 2, 2, 2},
+			[12]int{50, 50, 50, 50, 50, 50}, -1},
+		// 10% to 12% is less than a 25% increase.
+		{"small relative rise",
+			[12]int{10, 10, 10, 12, 12, 12},
+			[12]int{100, 100, 100, 100, 100, 100}, 0},
+		// 0.4% to 0.8% doubles, but by less than one point.
+		{"small absolute rise",
+			[12]int{2, 2, 2, 4, 4, 4},
+			[12]int{500, 500, 500, 500, 500, 500}, 0},
+		// Months without messages are skipped when choosing the
+		// recent three.
+		{"gaps skipped",
+			[12]int{6, 0, 6, 0, 6, 2, 0, 2, 2},
+			[12]int{50, 0, 50, 0, 50, 50, 0, 50, 50}, -1},
+	}
+	for _, tt := range tests {
+		if got := problemTrend(tt.counts, tt.messages); got != tt.want {
+			t.Errorf("%s: problemTrend = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
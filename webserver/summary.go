@@ -0,0 +1,43 @@
+package webserver
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rothskeller/wppsvr/report"
+)
+
+// serveSummary handles GET /summary requests.  It displays a summary report for
+// a year (given by the "year" parameter), a month (given by "year" and "month"
+// parameters), or an arbitrary date range (given by "start" and "end"
+// parameters, both inclusive).
+func (ws *webserver) serveSummary(w http.ResponseWriter, r *http.Request) {
+	var start, end time.Time
+
+	if callsign := ws.checkLoggedIn(w, r); callsign == "" {
+		return
+	}
+	if s, err := time.ParseInLocation("2006-01-02", r.FormValue("start"), time.Local); err == nil {
+		start = s
+		if e, err := time.ParseInLocation("2006-01-02", r.FormValue("end"), time.Local); err == nil && !e.Before(s) {
+			end = e.AddDate(0, 0, 1)
+		}
+	} else if y, err := strconv.Atoi(r.FormValue("year")); err == nil && y > 2000 && y < 3000 {
+		if m, err := strconv.Atoi(r.FormValue("month")); err == nil && m >= 1 && m <= 12 {
+			start = time.Date(y, time.Month(m), 1, 0, 0, 0, 0, time.Local)
+			end = start.AddDate(0, 1, 0)
+		} else {
+			start = time.Date(y, 1, 1, 0, 0, 0, 0, time.Local)
+			end = start.AddDate(1, 0, 0)
+		}
+	}
+	if start.IsZero() || end.IsZero() {
+		http.Error(w, "400 Bad Request", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Cache-Control", "nostore")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	io.WriteString(w, report.GenerateSummary(ws.st, start, end).RenderHTML())
+}
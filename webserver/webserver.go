@@ -38,10 +38,16 @@ func (ws *webserver) Setup() {
 	http.Handle("/instructions", http.HandlerFunc(ws.serveInstructions))
 	http.Handle("/login", http.HandlerFunc(ws.serveLogin))
 	http.Handle("/message", http.HandlerFunc(ws.serveMessage))
+	http.Handle("/profile", http.HandlerFunc(ws.serveProfile))
+	http.Handle("/profile/confirm", http.HandlerFunc(ws.serveConfirmEmail))
 	http.Handle("/report", http.HandlerFunc(ws.serveReport))
+	http.Handle("/reports.atom", http.HandlerFunc(ws.serveAtom))
 	http.Handle("/session", http.HandlerFunc(ws.serveSessionEdit))
 	http.Handle("/session/image", http.HandlerFunc(ws.serveModelImage))
 	http.Handle("/sessions", http.HandlerFunc(ws.serveSessionList))
+	http.Handle("/sessions.ics", http.HandlerFunc(ws.serveICS))
+	http.Handle("/stats", http.HandlerFunc(ws.serveStats))
+	http.Handle("/summary", http.HandlerFunc(ws.serveSummary))
 	http.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.FS(static))))
 }
 